@@ -2,10 +2,12 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"net/http"
 	"os"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/client"
 	"github.com/gizmoguy/exabgp_exporter/pkg/exporter"
 
 	"github.com/go-kit/log/level"
@@ -25,10 +27,18 @@ var (
 func main() {
 
 	var (
-		_             = kingpin.Command("stream", "run in stream mode (appropriate for embedding as an exabgp process)")
+		streamCmd     = kingpin.Command("stream", "run in stream mode (appropriate for embedding as an exabgp process)")
+		streamTimeout = streamCmd.Flag("stream.timeout", "report exabgp as down when no event was received for this long (0 to disable)").Default("0s").Duration()
+		streamExit    = streamCmd.Flag("stream.exit-on-close", "exit once exabgp closes the event stream instead of reporting it as down").Bool()
 		shellCmd      = kingpin.Command("standalone", "run in standalone mode (calls exabgpcli on each scrape)").Default()
 		exabgpcmd     = shellCmd.Flag("exabgp.cli.command", "exabgpcli command").Default(exaBGPCLICommand).String()
 		exabgproot    = shellCmd.Flag("exabgp.root", "value of --root to be passed to exabgpcli").Default(exaBGPCLIRoot).String()
+		control       = shellCmd.Flag("exabgp.control", "how to talk to exabgp: run exabgpcli or use its named pipes or unix socket directly").Default("exabgpcli").Enum("exabgpcli", "pipe", "socket")
+		controlName   = shellCmd.Flag("exabgp.control.name", "name of the exabgp pipes or socket (exabgp.api.pipename)").Default(client.DefaultName).String()
+		adjRibIn      = shellCmd.Flag("exabgp.adj-rib-in", "also export the routes received from peers (show adj-rib in)").Bool()
+		neighborExt   = shellCmd.Flag("exabgp.neighbor-extensive", "query the neighbors with show neighbor extensive for their message counters and session details").Bool()
+		pollInterval  = shellCmd.Flag("exabgp.poll-interval", "poll exabgp in the background at this interval and serve the last results on scrapes (0 to query exabgp on each scrape)").Default("0s").Duration()
+		cliTimeout    = shellCmd.Flag("exabgp.cli.timeout", "timeout of the exabgp commands run for a scrape (0 to follow the scrape timeout)").Default("0s").Duration()
 		listenAddress = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9576").String()
 		metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
 	)
@@ -41,6 +51,7 @@ func main() {
 	exporterMode := kingpin.Parse()
 
 	logger := promlog.New(promlogConfig)
+	var handler http.Handler
 
 	switch exporterMode {
 	case "standalone":
@@ -51,6 +62,7 @@ func main() {
 			"mode", "standalone",
 			"args", *exabgpcmd,
 			"root", *exabgproot,
+			"control", *control,
 		)
 		level.Info(logger).Log("buildcontext", version.BuildContext()) // nolint:errcheck
 		e, err := exporter.NewStandaloneExporter(*exabgpcmd, *exabgproot, logger)
@@ -58,8 +70,26 @@ func main() {
 			level.Error(logger).Log("err", err) // nolint:errcheck
 			os.Exit(1)
 		}
-		prometheus.MustRegister(e)
+		switch *control {
+		case "pipe":
+			e.Client, err = client.NewPipe(*exabgproot, *controlName)
+		case "socket":
+			e.Client, err = client.NewSocket(*exabgproot, *controlName)
+		}
+		if err != nil {
+			level.Error(logger).Log("err", err) // nolint:errcheck
+			os.Exit(1)
+		}
+		e.Timeout = *cliTimeout
+		e.AdjRibIn = *adjRibIn
+		e.NeighborExtensive = *neighborExt
+		e.Interval = *pollInterval
+		if e.Interval > 0 {
+			e.Run(context.Background())
+		}
 		prometheus.MustRegister(versioncollector.NewCollector("exabgp_exporter"))
+		// the exporter is collected with the timeout of each scrape
+		handler = promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, e.Handler())
 	case "stream":
 		// nolint:errcheck
 		level.Info(logger).Log(
@@ -68,18 +98,26 @@ func main() {
 			"mode", "stream",
 		)
 		level.Info(logger).Log("buildcontext", version.BuildContext()) // nolint:errcheck
-		e, err := exporter.NewEmbeddedExporter(logger)
+		e, err := exporter.NewEmbeddedExporter(*streamTimeout, logger)
 		if err != nil {
 			level.Error(logger).Log("err", err) // nolint:errcheck
 			os.Exit(1)
 		}
 		prometheus.MustRegister(e)
 		prometheus.MustRegister(versioncollector.NewCollector("exabgp_exporter"))
+		handler = promhttp.Handler()
 		reader := bufio.NewReader(os.Stdin)
 		e.Run(reader)
+		if *streamExit {
+			go func() {
+				<-e.Done()
+				level.Info(logger).Log("msg", "exabgp closed the event stream, exiting") // nolint:errcheck
+				os.Exit(0)
+			}()
+		}
 	}
 	level.Info(logger).Log("msg", "Listening on", "address", *listenAddress) // nolint:errcheck
-	http.Handle(*metricsPath, promhttp.Handler())
+	http.Handle(*metricsPath, handler)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<html>
              <head><title>ExaBGP Exporter</title></head>
@@ -0,0 +1,108 @@
+// Package client talks to a running exabgp over its control channel, the
+// named pipes or unix socket exabgpcli uses, without forking exabgpcli.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// exabgp ends each answer with one of these lines
+const (
+	answerDone     = "done"
+	answerError    = "error"
+	answerShutdown = "shutdown"
+)
+
+// DefaultName is the name of the pipes (<name>.in and <name>.out) and socket
+// (<name>.sock) unless exabgp.api.pipename is set in the exabgp environment
+const DefaultName = "exabgp"
+
+// DefaultTimeout is how long to wait for exabgp to answer when the context
+// has no deadline
+const DefaultTimeout = 5 * time.Second
+
+var (
+	// ErrCommand is returned when exabgp answers a command with an error
+	ErrCommand = errors.New("exabgp returned an error")
+	// ErrShutdown is returned when exabgp shuts down while answering
+	ErrShutdown = errors.New("exabgp is shutting down")
+	// ErrNotFound is returned when the pipes or socket can't be found
+	ErrNotFound = errors.New("unable to find the exabgp control channel")
+)
+
+// Client sends commands to exabgp, as they would be given to exabgpcli
+// ("show neighbor summary"), and returns the answer without its terminator.
+// It gives up at the deadline of the context, or after its own timeout when
+// the context has none, with an error wrapping os.ErrDeadlineExceeded.
+type Client interface {
+	Send(ctx context.Context, command string) ([]byte, error)
+}
+
+// deadline returns the deadline of the context, the timeout only applies to
+// contexts without one so that it doesn't cut short a longer scrape
+func deadline(ctx context.Context, timeout time.Duration) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(timeout)
+}
+
+// readAnswer reads the lines of an answer up to its terminator
+func readAnswer(r io.Reader) ([]byte, error) {
+	var answer bytes.Buffer
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return answer.Bytes(), fmt.Errorf("unable to read answer: %w", err)
+		}
+		switch strings.TrimRight(line, "\r\n") {
+		case answerDone:
+			return answer.Bytes(), nil
+		case answerError:
+			return answer.Bytes(), ErrCommand
+		case answerShutdown:
+			return answer.Bytes(), ErrShutdown
+		}
+		answer.WriteString(line)
+	}
+}
+
+// locations returns the directories exabgpcli looks for the control
+// channel in, those under the root come first
+func locations(root string) []string {
+	var dirs []string
+	for _, base := range []string{root, "/"} {
+		for _, dir := range []string{"run", filepath.Join("var", "run")} {
+			dirs = append(dirs,
+				filepath.Join(base, dir, "exabgp"),
+				filepath.Join(base, dir, fmt.Sprintf("%d", os.Getuid())),
+				filepath.Join(base, dir),
+			)
+		}
+	}
+	return dirs
+}
+
+// find returns the first existing file among the locations
+func find(root string, file string) (string, error) {
+	for _, dir := range locations(root) {
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no %s under %s or /run", ErrNotFound, file, root)
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAnswer(t *testing.T) {
+	tc := map[string]struct {
+		answer string
+		body   string
+		err    error
+	}{
+		"done":     {"neighbor 192.168.1.2 up\ndone\n", "neighbor 192.168.1.2 up\n", nil},
+		"empty":    {"done\n", "", nil},
+		"error":    {"error\n", "", ErrCommand},
+		"shutdown": {"shutdown\n", "", ErrShutdown},
+		"crlf":     {"exabgp 4.2.11\r\ndone\r\n", "exabgp 4.2.11\r\n", nil},
+		// the output is left alone, only the terminator line counts
+		"done in a line": {"done 2\ndone\n", "done 2\n", nil},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			body, err := readAnswer(strings.NewReader(test.answer))
+			require.Equal(t, test.err, err)
+			require.Equal(t, test.body, string(body))
+		})
+	}
+
+	_, err := readAnswer(strings.NewReader("neighbor 192.168.1.2 up\n"))
+	require.Error(t, err)
+}
+
+func TestFindNotFound(t *testing.T) {
+	_, err := find(t.TempDir(), "exabgp-test-missing.in")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Pipe talks to exabgp over its named pipes, commands are written to
+// <name>.in and answers read from <name>.out
+type Pipe struct {
+	In      string
+	Out     string
+	Timeout time.Duration
+	// the pipes carry one command at a time
+	mutex sync.Mutex
+}
+
+// NewPipe returns a client for the pipes named name, found where exabgpcli
+// looks for them given the exabgp root
+func NewPipe(root string, name string) (*Pipe, error) {
+	in, err := find(root, name+".in")
+	if err != nil {
+		return nil, err
+	}
+	out, err := find(root, name+".out")
+	if err != nil {
+		return nil, err
+	}
+	return &Pipe{In: in, Out: out, Timeout: DefaultTimeout}, nil
+}
+
+// Send writes a command to exabgp and waits for its answer
+func (p *Pipe) Send(ctx context.Context, command string) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	deadline := deadline(ctx, p.Timeout)
+
+	// the reading end is opened first, exabgp only answers when someone
+	// is listening
+	out, err := os.OpenFile(p.Out, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", p.Out, err)
+	}
+	defer out.Close()
+	// whatever is left over is the answer to a command whose client gave up
+	p.drain(out)
+
+	in, err := os.OpenFile(p.In, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		// ENXIO, nobody reads the pipe
+		return nil, fmt.Errorf("unable to open %s, is exabgp running: %w", p.In, err)
+	}
+	_, err = in.Write([]byte(command + "\n"))
+	in.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to send command: %w", err)
+	}
+
+	return readAnswer(&pipeReader{ctx: ctx, file: out, deadline: deadline})
+}
+
+func (p *Pipe) drain(out *os.File) {
+	buf := make([]byte, 4096)
+	for {
+		if err := out.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+			return
+		}
+		if _, err := out.Read(buf); err != nil {
+			return
+		}
+	}
+}
+
+// pipeReader reads a non blocking pipe until the deadline, reading returns
+// EOF whenever exabgp doesn't have the pipe open for writing
+type pipeReader struct {
+	ctx      context.Context
+	file     *os.File
+	deadline time.Time
+}
+
+func (r *pipeReader) Read(b []byte) (int, error) {
+	for {
+		if err := r.file.SetReadDeadline(r.deadline); err != nil {
+			return 0, err
+		}
+		n, err := r.file.Read(b)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				err = fmt.Errorf("no answer from exabgp: %w", err)
+			}
+			return n, err
+		}
+		if time.Now().After(r.deadline) {
+			return 0, fmt.Errorf("no answer from exabgp: %w", os.ErrDeadlineExceeded)
+		}
+		if err := r.ctx.Err(); err != nil {
+			return 0, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !windows
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPipes creates the pipes exabgp would under root/run
+func testPipes(t *testing.T) (string, string, string) {
+	root := t.TempDir()
+	run := filepath.Join(root, "run")
+	require.NoError(t, os.Mkdir(run, 0o755))
+	in, out := filepath.Join(run, "exabgp.in"), filepath.Join(run, "exabgp.out")
+	require.NoError(t, syscall.Mkfifo(in, 0o600))
+	require.NoError(t, syscall.Mkfifo(out, 0o600))
+	return root, in, out
+}
+
+// testExabgp answers a single command on the pipes like exabgp does
+func testExabgp(t *testing.T, in string, out string, answer string) <-chan string {
+	// exabgp keeps its end of the input pipe open
+	r, err := os.OpenFile(in, os.O_RDWR, 0)
+	require.NoError(t, err)
+	commands := make(chan string, 1)
+	go func() {
+		defer r.Close()
+		command, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil {
+			close(commands)
+			return
+		}
+		commands <- command
+		w, err := os.OpenFile(out, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer w.Close()
+		_, _ = w.Write([]byte(answer))
+	}()
+	return commands
+}
+
+func TestPipeSend(t *testing.T) {
+	root, in, out := testPipes(t)
+	p, err := NewPipe(root, DefaultName)
+	require.NoError(t, err)
+	require.Equal(t, in, p.In)
+	require.Equal(t, out, p.Out)
+
+	commands := testExabgp(t, in, out, "Peer            AS        up/down state       |     #sent     #recvd\n127.0.0.1       64496        down idle                  0          0\ndone\n")
+	answer, err := p.Send(context.Background(), "show neighbor summary")
+	require.NoError(t, err)
+	require.Equal(t, "show neighbor summary\n", <-commands)
+	require.Equal(t, "Peer            AS        up/down state       |     #sent     #recvd\n127.0.0.1       64496        down idle                  0          0\n", string(answer))
+
+	testExabgp(t, in, out, "error\n")
+	_, err = p.Send(context.Background(), "show nonsense")
+	require.ErrorIs(t, err, ErrCommand)
+}
+
+func TestPipeNotRunning(t *testing.T) {
+	root, _, _ := testPipes(t)
+	p, err := NewPipe(root, DefaultName)
+	require.NoError(t, err)
+	// nobody reads the input pipe
+	_, err = p.Send(context.Background(), "show neighbor summary")
+	require.Error(t, err)
+}
+
+func TestPipeTimeout(t *testing.T) {
+	root, in, _ := testPipes(t)
+	p, err := NewPipe(root, DefaultName)
+	require.NoError(t, err)
+	p.Timeout = 100 * time.Millisecond
+
+	// exabgp reads the command but never answers
+	r, err := os.OpenFile(in, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = p.Send(context.Background(), "show neighbor summary")
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// the deadline of the context wins when it comes first
+	p.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = p.Send(ctx, "show neighbor summary")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 10*time.Second)
+
+	// and when it comes after the timeout, which is only for contexts
+	// without a deadline
+	p.Timeout = 50 * time.Millisecond
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = p.Send(ctx, "show neighbor summary")
+	require.Error(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+}
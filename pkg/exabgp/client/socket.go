@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Socket talks to exabgp over its unix socket, as exabgp 5 does
+type Socket struct {
+	Path    string
+	Timeout time.Duration
+}
+
+// NewSocket returns a client for the socket named name, found where
+// exabgpcli looks for it given the exabgp root
+func NewSocket(root string, name string) (*Socket, error) {
+	path, err := find(root, name+".sock")
+	if err != nil {
+		return nil, err
+	}
+	return &Socket{Path: path, Timeout: DefaultTimeout}, nil
+}
+
+// Send writes a command to exabgp and waits for its answer
+func (s *Socket) Send(ctx context.Context, command string) ([]byte, error) {
+	deadline := deadline(ctx, s.Timeout)
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "unix", s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s, is exabgp running: %w", s.Path, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte(command + "\n")); err != nil {
+		return nil, fmt.Errorf("unable to send command: %w", err)
+	}
+	return readAnswer(conn)
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketSend(t *testing.T) {
+	root := t.TempDir()
+	run := filepath.Join(root, "run")
+	require.NoError(t, os.Mkdir(run, 0o755))
+	l, err := net.Listen("unix", filepath.Join(run, "exabgp.sock"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	commands := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		command, _ := bufio.NewReader(conn).ReadString('\n')
+		commands <- command
+		_, _ = conn.Write([]byte("exabgp 5.0.0\ndone\n"))
+	}()
+
+	s, err := NewSocket(root, DefaultName)
+	require.NoError(t, err)
+	answer, err := s.Send(context.Background(), "version")
+	require.NoError(t, err)
+	require.Equal(t, "version\n", <-commands)
+	require.Equal(t, "exabgp 5.0.0\n", string(answer))
+}
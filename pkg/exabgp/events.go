@@ -5,41 +5,40 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages"
 )
 
-var lastStatus *status
-
-type status struct {
-	state  string
-	reason string
-}
-
-// GetStatus returns the last known status of the exabgp instance
-func GetStatus() string {
-	if lastStatus == nil {
-		lastStatus = &status{
-			state:  "unknown",
-			reason: "no known last status",
-		}
-	}
-	return lastStatus.state
-}
-
-// GetStatusReason returns any reason we may have for the current status
-func GetStatusReason() string {
-	return lastStatus.reason
-}
-
 // Event represents a fully parsed event
 type Event struct {
 	messages.BaseEvent
-	Peer          Peer
-	Self          Self
-	Direction     string
-	announcements *Announcements
-	withdrawals   *Withdrawals
+	Peer      Peer
+	Self      Self
+	Direction string
+	// UnknownFamilies lists the families in an update that have no
+	// registered decoder, their routes are skipped
+	UnknownFamilies []string
+	// FamilyErrors holds why the families of an update failed to decode,
+	// their routes are skipped while those of the other families are kept
+	FamilyErrors map[string]error
+	// Notification is set for notification events sent to or received from a peer
+	Notification *Notification
+	// Open is set for open events sent to or received from a peer
+	Open *Open
+	// Shutdown is set when exabgp announces it is shutting down
+	Shutdown bool
+	// Signal is set for signal events
+	Signal *Signal
+	// Restarted is set by Parser on the first event of a new exabgp process
+	Restarted bool
+	// EOR is the "<afi> <safi>" family of an End-of-RIB update
+	EOR string
+	// Convergence is set by Parser on the first End-of-RIB of a family since
+	// the session was established, to the time it took to get there
+	Convergence   time.Duration
+	announcements Announcements
+	withdrawals   Withdrawals
 	sync.RWMutex
 }
 
@@ -49,31 +48,41 @@ func (e *Event) GetVersion() string {
 }
 
 // GetWithdrawals gets all the withdraw messages in the event
-func (e *Event) GetWithdrawals() *Withdrawals {
+func (e *Event) GetWithdrawals() Withdrawals {
 	e.RLock()
 	defer e.RUnlock()
 	return e.withdrawals
 }
 
-func (e *Event) setWithdrawals(rw *Withdrawals) {
+func (e *Event) setWithdrawals(rw Withdrawals) {
 	e.Lock()
 	e.withdrawals = rw
 	e.Unlock()
 }
 
 // GetAnnouncements gets all the announce messages in the event
-func (e *Event) GetAnnouncements() *Announcements {
+func (e *Event) GetAnnouncements() Announcements {
 	e.RLock()
 	defer e.RUnlock()
 	return e.announcements
 }
 
-func (e *Event) setAnnouncements(ra *Announcements) {
+func (e *Event) setAnnouncements(ra Announcements) {
 	e.Lock()
 	e.announcements = ra
 	e.Unlock()
 }
 
+// IsMessage reports whether the event is a bgp message sent to or received
+// from a peer rather than a change of state or a signal
+func (e *Event) IsMessage() bool {
+	switch e.Type {
+	case "open", "keepalive", "update", "notification", "refresh":
+		return e.Direction != ""
+	}
+	return false
+}
+
 // Peer represents a neighbor and its state
 type Peer struct {
 	IP     string
@@ -88,72 +97,41 @@ type Self struct {
 	ASN int
 }
 
-// Announcements represents all the bgp `announce` messages
-type Announcements struct {
-	IPV4Unicast map[string]*IPv4UnicastAnnouncement
-	IPV4Flow    map[string]*IPv4FlowAnnouncement
-	IPV6Unicast map[string]*IPv6UnicastAnnouncement
-	IPV6Flow    map[string]*IPv6FlowAnnouncement
-}
+// Announcements represents all the bgp `announce` messages keyed by family
+// and then next-hop
+type Announcements map[string]map[string]*Announcement
 
-// Withdrawals represents all the bgp `withdraw` messages
-type Withdrawals struct {
-	IPv4Unicast []IPv4UnicastWithdrawal
-	IPv4Flow    []IPv4Flow
-	IPv6Unicast []IPv6UnicastWithdrawal
-	IPv6Flow    []IPv6Flow
-}
+// Withdrawals represents all the bgp `withdraw` messages keyed by family
+type Withdrawals map[string]*Withdrawal
 
-// IPv4UnicastAnnouncement represents an `ipv4 unicast` family announce
-type IPv4UnicastAnnouncement struct {
+// Announcement represents the routes announced in a family via a next-hop
+type Announcement struct {
 	Attributes messages.Attribute
-	NLRI       []string
+	Routes     []*Route
 }
 
-// IPv4UnicastWithdrawal represents an 'ipv4 unicast' family route withdraw
-type IPv4UnicastWithdrawal struct {
-	Attributes messages.Attribute
-	NLRI       []string
+// NLRI returns the nlri of all the announced routes
+func (a *Announcement) NLRI() []string {
+	return routesNLRI(a.Routes)
 }
 
-// IPv4FlowAnnouncement represents an 'ipv4 flow' flow announce
-type IPv4FlowAnnouncement struct {
+// Withdrawal represents the routes withdrawn in a family
+type Withdrawal struct {
 	Attributes messages.Attribute
-	Flows      []IPv4Flow
+	Routes     []*Route
 }
 
-// IPv4Flow represents an 'ipv4 flow'
-type IPv4Flow struct {
-	Attributes  messages.Attribute
-	Destination []string
-	Source      []string
-	String      string
+// NLRI returns the nlri of all the withdrawn routes
+func (w *Withdrawal) NLRI() []string {
+	return routesNLRI(w.Routes)
 }
 
-// IPv6UnicastAnnouncement represents an `ipv6 unicast` family announce
-type IPv6UnicastAnnouncement struct {
-	Attributes messages.Attribute
-	NLRI       []string
-}
-
-// IPv6UnicastWithdrawal represents an 'ipv6 unicast' family route withdraw
-type IPv6UnicastWithdrawal struct {
-	Attributes messages.Attribute
-	NLRI       []string
-}
-
-// IPv6FlowAnnouncement represents an 'ipv6 flow' flow announce
-type IPv6FlowAnnouncement struct {
-	Attributes messages.Attribute
-	Flows      []IPv6Flow
-}
-
-// IPv6Flow represents an 'ipv6 flow'
-type IPv6Flow struct {
-	Attributes  messages.Attribute
-	Destination []string
-	Source      []string
-	String      string
+func routesNLRI(routes []*Route) []string {
+	nlri := make([]string, 0, len(routes))
+	for _, r := range routes {
+		nlri = append(nlri, r.NLRI)
+	}
+	return nlri
 }
 
 // This tries to fix any non-utf8 json generated by exabgp
@@ -188,172 +166,97 @@ func ParseEvent(data []byte) (*Event, error) {
 	}
 	switch jsonEvent.Type {
 	case "update":
-		ra, rw, err := parseUpdateMessage(jsonEvent.Neighbor.Message.Update)
-		if err != nil {
-			return event, err
+		if eor := jsonEvent.Neighbor.Message.EOR; eor != nil {
+			event.EOR = eor.AFI + " " + eor.SAFI
+			return event, nil
 		}
+		ra, rw, unknown, errs := parseUpdateMessage(jsonEvent.Neighbor.Message.Update)
 		event.setAnnouncements(ra)
 		event.setWithdrawals(rw)
+		event.UnknownFamilies = unknown
+		event.FamilyErrors = errs
 	case "state":
 		event.Peer.Reason = jsonEvent.Neighbor.Reason
 		event.Peer.State = jsonEvent.Neighbor.State
-		lastStatus = &status{
-			state:  event.Peer.State,
-			reason: event.Peer.Reason,
-		}
 	case "notification":
-		// nothing yet
+		// exabgp also uses this type for its own shutdown message which
+		// has no neighbor
+		event.Shutdown = jsonEvent.Shutdown == "shutdown"
+		if n := jsonEvent.Neighbor.Notification; n != nil {
+			event.Notification = &Notification{
+				Code:    n.Code,
+				SubCode: n.SubCode,
+				Data:    n.Data,
+			}
+		}
 	case "open":
-		// we don't do these right now
+		if o := jsonEvent.Neighbor.Open; o != nil {
+			event.Open = newOpen(o)
+		}
 	case "keepalive":
-		// nothing to do yet
-		// increment counter in future?
+		// nothing to do, only counted
+	case "refresh":
+		// route refresh requests are only counted
 	case "signal":
-		// nothing yet
+		code, _ := jsonEvent.Neighbor.Code.Int64()
+		event.Signal = &Signal{
+			Code: int(code),
+			Name: jsonEvent.Neighbor.Name,
+		}
 	default:
 		return nil, fmt.Errorf("Cannot handle event type: %s [data: %s]", jsonEvent.Type, data)
 	}
 	return event, nil
 }
 
-func makeAnnouncements() *Announcements {
-	return &Announcements{
-		IPV4Unicast: make(map[string]*IPv4UnicastAnnouncement),
-		IPV4Flow:    make(map[string]*IPv4FlowAnnouncement),
-		IPV6Unicast: make(map[string]*IPv6UnicastAnnouncement),
-		IPV6Flow:    make(map[string]*IPv6FlowAnnouncement),
-	}
-}
-
-func parseUpdateMessage(u messages.UpdateMessageFull) (*Announcements, *Withdrawals, error) {
-	ra := makeAnnouncements()
-	rw := &Withdrawals{}
-
-	// ipv4-unicast announce
-	for nexthop, routes := range u.Announce.IPv4Unicast {
-		if _, ok := ra.IPV4Unicast[nexthop]; !ok {
-			ra.IPV4Unicast[nexthop] = &IPv4UnicastAnnouncement{}
+func parseUpdateMessage(u messages.UpdateMessageFull) (Announcements, Withdrawals, []string, map[string]error) {
+	ra := make(Announcements)
+	rw := make(Withdrawals)
+	unknown := []string{}
+	errs := make(map[string]error)
+
+	for family, nexthops := range u.Announce {
+		decode, ok := familyDecoder(family)
+		if !ok {
+			unknown = append(unknown, family)
+			continue
 		}
-		for _, rs := range routes {
-			switch r := rs.(type) {
-			case string:
-				ra.IPV4Unicast[nexthop].NLRI = append(ra.IPV4Unicast[nexthop].NLRI, r)
-			case map[string]interface{}:
-				for k, v := range r {
-					if _, ok := v.(string); !ok {
-						return nil, nil, fmt.Errorf("got a non-string value for %s: %s", k, v)
-					}
-					ra.IPV4Unicast[nexthop].NLRI = append(ra.IPV4Unicast[nexthop].NLRI, v.(string))
-				}
-			default:
-				return nil, nil, fmt.Errorf("unable to parse route: %+v", rs)
+		announcements := make(map[string]*Announcement)
+		for nexthop, data := range nexthops {
+			routes, err := decode(data)
+			if err != nil {
+				errs[family] = err
+				break
 			}
-		}
-		ra.IPV4Unicast[nexthop].Attributes = u.Attribute
-	}
-
-	// ipv4-unicast withdraws
-	ws4 := []string{}
-	for _, r := range u.Withdraw.IPv4Unicast {
-		switch r := r.(type) {
-		case string:
-			ws4 = append(ws4, r)
-		case map[string]interface{}:
-			for k, v := range r {
-				if _, ok := v.(string); !ok {
-					return nil, nil, fmt.Errorf("got a non-string value for %s: %s", k, v)
-				}
-				ws4 = append(ws4, v.(string))
+			for _, r := range routes {
+				r.Family = family
+				r.NextHop = nexthop
+				r.Attributes = u.Attribute
 			}
-
-		default:
-			return nil, nil, fmt.Errorf("unable to parse route: %+v", r)
+			announcements[nexthop] = &Announcement{Attributes: u.Attribute, Routes: routes}
 		}
-	}
-	rw.IPv4Unicast = append(rw.IPv4Unicast, IPv4UnicastWithdrawal{Attributes: u.Attribute, NLRI: ws4})
-
-	// ipv4-flow announce
-	for nexthop, flows := range u.Announce.IPv4Flow {
-		if _, ok := ra.IPV4Flow[nexthop]; !ok {
-			ra.IPV4Flow[nexthop] = &IPv4FlowAnnouncement{}
+		if errs[family] == nil {
+			ra[family] = announcements
 		}
-		for _, flow := range flows {
-			f := IPv4Flow{
-				Destination: flow.DestinationIPv4,
-				Source:      flow.SourceIPv4,
-				String:      flow.String,
-			}
-			ra.IPV4Flow[nexthop].Flows = append(ra.IPV4Flow[nexthop].Flows, f)
-		}
-		ra.IPV4Flow[nexthop].Attributes = u.Attribute
 	}
 
-	for _, flow := range u.Withdraw.IPv4Flow {
-		rw.IPv4Flow = append(rw.IPv4Flow, IPv4Flow{Attributes: u.Attribute, Destination: flow.DestinationIPv4, Source: flow.SourceIPv4, String: flow.String})
-	}
-
-	// ipv6-unicast announce
-	for nexthop, routes := range u.Announce.IPv6Unicast {
-		if _, ok := ra.IPV6Unicast[nexthop]; !ok {
-			ra.IPV6Unicast[nexthop] = &IPv6UnicastAnnouncement{}
-		}
-		for _, rs := range routes {
-			switch r := rs.(type) {
-			case string:
-				ra.IPV6Unicast[nexthop].NLRI = append(ra.IPV6Unicast[nexthop].NLRI, r)
-			case map[string]interface{}:
-				for k, v := range r {
-					if _, ok := v.(string); !ok {
-						return nil, nil, fmt.Errorf("got a non-string value for %s: %s", k, v)
-					}
-					ra.IPV6Unicast[nexthop].NLRI = append(ra.IPV6Unicast[nexthop].NLRI, v.(string))
-				}
-			default:
-				return nil, nil, fmt.Errorf("unable to parse route: %+v", rs)
-			}
-		}
-		ra.IPV6Unicast[nexthop].Attributes = u.Attribute
-	}
-
-	// ipv6-unicast withdraws
-	ws6 := []string{}
-	for _, r := range u.Withdraw.IPv6Unicast {
-		switch r := r.(type) {
-		case string:
-			ws6 = append(ws6, r)
-		case map[string]interface{}:
-			for k, v := range r {
-				if _, ok := v.(string); !ok {
-					return nil, nil, fmt.Errorf("got a non-string value for %s: %s", k, v)
-				}
-				ws6 = append(ws6, v.(string))
-			}
-
-		default:
-			return nil, nil, fmt.Errorf("unable to parse route: %+v", r)
+	for family, data := range u.Withdraw {
+		decode, ok := familyDecoder(family)
+		if !ok {
+			unknown = append(unknown, family)
+			continue
 		}
-	}
-	rw.IPv6Unicast = append(rw.IPv6Unicast, IPv6UnicastWithdrawal{Attributes: u.Attribute, NLRI: ws6})
-
-	// ipv6-flow announce
-	for nexthop, flows := range u.Announce.IPv6Flow {
-		if _, ok := ra.IPV6Flow[nexthop]; !ok {
-			ra.IPV6Flow[nexthop] = &IPv6FlowAnnouncement{}
+		routes, err := decode(data)
+		if err != nil {
+			errs[family] = err
+			continue
 		}
-		for _, flow := range flows {
-			f := IPv6Flow{
-				Destination: flow.DestinationIPv6,
-				Source:      flow.SourceIPv6,
-				String:      flow.String,
-			}
-			ra.IPV6Flow[nexthop].Flows = append(ra.IPV6Flow[nexthop].Flows, f)
+		for _, r := range routes {
+			r.Family = family
+			r.Attributes = u.Attribute
 		}
-		ra.IPV6Flow[nexthop].Attributes = u.Attribute
-	}
-
-	for _, flow := range u.Withdraw.IPv6Flow {
-		rw.IPv6Flow = append(rw.IPv6Flow, IPv6Flow{Attributes: u.Attribute, Destination: flow.DestinationIPv6, Source: flow.SourceIPv6, String: flow.String})
+		rw[family] = &Withdrawal{Attributes: u.Attribute, Routes: routes}
 	}
 
-	return ra, rw, nil
+	return ra, rw, unknown, errs
 }
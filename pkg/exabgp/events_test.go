@@ -84,12 +84,12 @@ func TestIPv4Announce(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "4.0.1", evt.GetVersion())
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV4Unicast["192.168.1.184"].Attributes.Med)
-	require.NotEmpty(t, announcements.IPV4Unicast["192.168.1.184"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV4Unicast["192.168.1.184"].Attributes.LocalPreference)
-	require.Len(t, announcements.IPV4Unicast, 1)
-	require.Len(t, announcements.IPV4Unicast["192.168.1.184"].NLRI, 1)
-	require.Contains(t, announcements.IPV4Unicast["192.168.1.184"].NLRI, "192.168.88.2/32")
+	require.NotEmpty(t, announcements["ipv4 unicast"]["192.168.1.184"].Attributes.Med)
+	require.NotEmpty(t, announcements["ipv4 unicast"]["192.168.1.184"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv4 unicast"]["192.168.1.184"].Attributes.LocalPreference)
+	require.Len(t, announcements["ipv4 unicast"], 1)
+	require.Len(t, announcements["ipv4 unicast"]["192.168.1.184"].NLRI(), 1)
+	require.Contains(t, announcements["ipv4 unicast"]["192.168.1.184"].NLRI(), "192.168.88.2/32")
 }
 
 func TestIPv4AnnounceMulti(t *testing.T) {
@@ -98,11 +98,11 @@ func TestIPv4AnnounceMulti(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "4.0.1", evt.GetVersion())
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV4Unicast["192.168.1.184"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV4Unicast["192.168.1.184"].Attributes.LocalPreference)
-	require.Len(t, announcements.IPV4Unicast, 1)
-	require.Len(t, announcements.IPV4Unicast["192.168.1.184"].NLRI, 5)
-	require.Contains(t, announcements.IPV4Unicast["192.168.1.184"].NLRI, "192.168.88.0/24")
+	require.NotEmpty(t, announcements["ipv4 unicast"]["192.168.1.184"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv4 unicast"]["192.168.1.184"].Attributes.LocalPreference)
+	require.Len(t, announcements["ipv4 unicast"], 1)
+	require.Len(t, announcements["ipv4 unicast"]["192.168.1.184"].NLRI(), 5)
+	require.Contains(t, announcements["ipv4 unicast"]["192.168.1.184"].NLRI(), "192.168.88.0/24")
 }
 
 func TestIPv4AnnounceFlow(t *testing.T) {
@@ -111,8 +111,8 @@ func TestIPv4AnnounceFlow(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "4.0.1", evt.GetVersion())
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV4Flow["no-nexthop"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV4Flow["no-nexthop"].Attributes.LocalPreference)
+	require.NotEmpty(t, announcements["ipv4 flow"]["no-nexthop"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv4 flow"]["no-nexthop"].Attributes.LocalPreference)
 	/*
 		{
 			"ipv4 flow": {
@@ -124,11 +124,11 @@ func TestIPv4AnnounceFlow(t *testing.T) {
 			}
 		}
 	*/
-	require.NotNil(t, announcements.IPV4Flow["no-nexthop"])
-	require.Len(t, announcements.IPV4Flow["no-nexthop"].Flows, 1)
-	require.Contains(t, announcements.IPV4Flow["no-nexthop"].Flows[0].Destination, "170.170.170.170/32")
-	require.Contains(t, announcements.IPV4Flow["no-nexthop"].Flows[0].Source, "170.170.170.170/32")
-	require.Equal(t, "flow destination-ipv4 170.170.170.170/32 source-ipv4 170.170.170.170/32", announcements.IPV4Flow["no-nexthop"].Flows[0].String)
+	require.NotNil(t, announcements["ipv4 flow"]["no-nexthop"])
+	require.Len(t, announcements["ipv4 flow"]["no-nexthop"].Routes, 1)
+	require.Contains(t, announcements["ipv4 flow"]["no-nexthop"].Routes[0].Flow.Destination, "170.170.170.170/32")
+	require.Contains(t, announcements["ipv4 flow"]["no-nexthop"].Routes[0].Flow.Source, "170.170.170.170/32")
+	require.Equal(t, "flow destination-ipv4 170.170.170.170/32 source-ipv4 170.170.170.170/32", announcements["ipv4 flow"]["no-nexthop"].Routes[0].NLRI)
 }
 
 func TestIPv4Withdraw(t *testing.T) {
@@ -138,8 +138,8 @@ func TestIPv4Withdraw(t *testing.T) {
 	require.Equal(t, "4.0.1", evt.GetVersion())
 	w := evt.GetWithdrawals()
 	require.NotNil(t, w)
-	require.Len(t, w.IPv4Unicast, 1)
-	require.Contains(t, w.IPv4Unicast[0].NLRI, "192.168.88.2/32")
+	require.Contains(t, w, "ipv4 unicast")
+	require.Contains(t, w["ipv4 unicast"].NLRI(), "192.168.88.2/32")
 }
 
 func TestIPv4WithdrawMulti(t *testing.T) {
@@ -149,9 +149,9 @@ func TestIPv4WithdrawMulti(t *testing.T) {
 	require.Equal(t, "4.0.1", evt.GetVersion())
 	w := evt.GetWithdrawals()
 	require.NotNil(t, w)
-	require.Len(t, w.IPv4Unicast, 1)
-	require.Len(t, w.IPv4Unicast[0].NLRI, 3)
-	require.Contains(t, w.IPv4Unicast[0].NLRI[2], "192.168.88.0/24")
+	require.Contains(t, w, "ipv4 unicast")
+	require.Len(t, w["ipv4 unicast"].NLRI(), 3)
+	require.Contains(t, w["ipv4 unicast"].NLRI()[2], "192.168.88.0/24")
 }
 
 func TestIPv6Announce(t *testing.T) {
@@ -162,12 +162,12 @@ func TestIPv6Announce(t *testing.T) {
 	require.Contains(t, evt.Self.IP, "2001::1")
 	require.Contains(t, evt.Peer.IP, "2001::2")
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV6Unicast["2001:db8:ffff::1"].Attributes.Med)
-	require.NotEmpty(t, announcements.IPV6Unicast["2001:db8:ffff::1"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV6Unicast["2001:db8:ffff::1"].Attributes.LocalPreference)
-	require.Len(t, announcements.IPV6Unicast, 1)
-	require.Len(t, announcements.IPV6Unicast["2001:db8:ffff::1"].NLRI, 1)
-	require.Contains(t, announcements.IPV6Unicast["2001:db8:ffff::1"].NLRI, "2001:db8:1000::/64")
+	require.NotEmpty(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].Attributes.Med)
+	require.NotEmpty(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].Attributes.LocalPreference)
+	require.Len(t, announcements["ipv6 unicast"], 1)
+	require.Len(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].NLRI(), 1)
+	require.Contains(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].NLRI(), "2001:db8:1000::/64")
 }
 
 func TestIPv6AnnounceMulti(t *testing.T) {
@@ -178,11 +178,11 @@ func TestIPv6AnnounceMulti(t *testing.T) {
 	require.Contains(t, evt.Self.IP, "2001::1")
 	require.Contains(t, evt.Peer.IP, "2001::2")
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV6Unicast["2001:db8:ffff::1"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV6Unicast["2001:db8:ffff::1"].Attributes.LocalPreference)
-	require.Len(t, announcements.IPV6Unicast, 1)
-	require.Len(t, announcements.IPV6Unicast["2001:db8:ffff::1"].NLRI, 5)
-	require.Contains(t, announcements.IPV6Unicast["2001:db8:ffff::1"].NLRI, "2001:db8:1000::/64")
+	require.NotEmpty(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].Attributes.LocalPreference)
+	require.Len(t, announcements["ipv6 unicast"], 1)
+	require.Len(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].NLRI(), 5)
+	require.Contains(t, announcements["ipv6 unicast"]["2001:db8:ffff::1"].NLRI(), "2001:db8:1000::/64")
 }
 
 func TestIPv6AnnounceFlow(t *testing.T) {
@@ -193,13 +193,13 @@ func TestIPv6AnnounceFlow(t *testing.T) {
 	require.Contains(t, evt.Self.IP, "2001::1")
 	require.Contains(t, evt.Peer.IP, "2001::2")
 	announcements := evt.GetAnnouncements()
-	require.NotEmpty(t, announcements.IPV6Flow["no-nexthop"].Attributes.Origin)
-	require.NotEmpty(t, announcements.IPV6Flow["no-nexthop"].Attributes.LocalPreference)
-	require.NotNil(t, announcements.IPV6Flow["no-nexthop"])
-	require.Len(t, announcements.IPV6Flow["no-nexthop"].Flows, 1)
-	require.Contains(t, announcements.IPV6Flow["no-nexthop"].Flows[0].Destination, "2001::1/128")
-	require.Contains(t, announcements.IPV6Flow["no-nexthop"].Flows[0].Source, "2001::2/32")
-	require.Equal(t, "flow destination-ipv6 2001::1/128 source-ipv6 2001::2/32", announcements.IPV6Flow["no-nexthop"].Flows[0].String)
+	require.NotEmpty(t, announcements["ipv6 flow"]["no-nexthop"].Attributes.Origin)
+	require.NotEmpty(t, announcements["ipv6 flow"]["no-nexthop"].Attributes.LocalPreference)
+	require.NotNil(t, announcements["ipv6 flow"]["no-nexthop"])
+	require.Len(t, announcements["ipv6 flow"]["no-nexthop"].Routes, 1)
+	require.Contains(t, announcements["ipv6 flow"]["no-nexthop"].Routes[0].Flow.Destination, "2001::1/128")
+	require.Contains(t, announcements["ipv6 flow"]["no-nexthop"].Routes[0].Flow.Source, "2001::2/32")
+	require.Equal(t, "flow destination-ipv6 2001::1/128 source-ipv6 2001::2/32", announcements["ipv6 flow"]["no-nexthop"].Routes[0].NLRI)
 }
 
 func TestIPv6Withdraw(t *testing.T) {
@@ -211,8 +211,8 @@ func TestIPv6Withdraw(t *testing.T) {
 	require.Contains(t, evt.Peer.IP, "2001::2")
 	w := evt.GetWithdrawals()
 	require.NotNil(t, w)
-	require.Len(t, w.IPv6Unicast, 1)
-	require.Contains(t, w.IPv6Unicast[0].NLRI, "2001:db8:1000::/64")
+	require.Contains(t, w, "ipv6 unicast")
+	require.Contains(t, w["ipv6 unicast"].NLRI(), "2001:db8:1000::/64")
 }
 
 func TestIPv6WithdrawMulti(t *testing.T) {
@@ -224,9 +224,9 @@ func TestIPv6WithdrawMulti(t *testing.T) {
 	require.Contains(t, evt.Peer.IP, "2001::2")
 	w := evt.GetWithdrawals()
 	require.NotNil(t, w)
-	require.Len(t, w.IPv6Unicast, 1)
-	require.Len(t, w.IPv6Unicast[0].NLRI, 3)
-	require.Contains(t, w.IPv6Unicast[0].NLRI[2], "2001:db8:3000::/64")
+	require.Contains(t, w, "ipv6 unicast")
+	require.Len(t, w["ipv6 unicast"].NLRI(), 3)
+	require.Contains(t, w["ipv6 unicast"].NLRI()[2], "2001:db8:3000::/64")
 }
 
 func TestPeerState(t *testing.T) {
@@ -237,11 +237,13 @@ func TestPeerState(t *testing.T) {
 	}
 	for name, test := range tc {
 		t.Run(name, func(t *testing.T) {
-			evt, err := ParseEvent([]byte(test))
+			p := NewParser()
+			evt, err := p.Parse([]byte(test))
 			require.NoError(t, err)
-			require.Equal(t, evt.Peer.State, GetStatus())
+			state, reason := p.PeerStatus(evt.Peer.IP)
+			require.Equal(t, evt.Peer.State, state)
 			if evt.Peer.State == "down" {
-				require.NotEmpty(t, GetStatusReason())
+				require.NotEmpty(t, reason)
 			}
 		})
 	}
@@ -255,10 +257,12 @@ func TestPeerError(t *testing.T) {
 	}
 	for name, test := range tc {
 		t.Run(name, func(t *testing.T) {
-			_, err := ParseEvent([]byte(test))
+			p := NewParser()
+			evt, err := p.Parse([]byte(test))
 			require.NoError(t, err)
-			require.Equal(t, "down", GetStatus())
-			require.Contains(t, GetStatusReason(), name)
+			state, reason := p.PeerStatus(evt.Peer.IP)
+			require.Equal(t, "down", state)
+			require.Contains(t, reason, name)
 		})
 	}
 }
@@ -271,11 +275,13 @@ func TestIPv6PeerState(t *testing.T) {
 	}
 	for name, test := range tc {
 		t.Run(name, func(t *testing.T) {
-			evt, err := ParseEvent([]byte(test))
+			p := NewParser()
+			evt, err := p.Parse([]byte(test))
 			require.NoError(t, err)
-			require.Equal(t, evt.Peer.State, GetStatus())
+			state, reason := p.PeerStatus(evt.Peer.IP)
+			require.Equal(t, evt.Peer.State, state)
 			if evt.Peer.State == "down" {
-				require.NotEmpty(t, GetStatusReason())
+				require.NotEmpty(t, reason)
 			}
 		})
 	}
@@ -289,10 +295,132 @@ func TestIPv6PeerError(t *testing.T) {
 	}
 	for name, test := range tc {
 		t.Run(name, func(t *testing.T) {
-			_, err := ParseEvent([]byte(test))
+			p := NewParser()
+			evt, err := p.Parse([]byte(test))
 			require.NoError(t, err)
-			require.Equal(t, "down", GetStatus())
-			require.Contains(t, GetStatusReason(), name)
+			state, reason := p.PeerStatus(evt.Peer.IP)
+			require.Equal(t, "down", state)
+			require.Contains(t, reason, name)
+		})
+	}
+}
+
+func TestIPv4AnnounceReceive(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554843224.518079, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "attribute": { "origin": "incomplete", "local-preference": 100 }, "announce": { "ipv4 unicast": { "75.138.131.57": [ "0.0.0.0/0" ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Equal(t, "receive", evt.Direction)
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements["ipv4 unicast"], 1)
+	require.Contains(t, announcements["ipv4 unicast"]["75.138.131.57"].NLRI(), "0.0.0.0/0")
+}
+
+func TestL2VPNVplsAnnounce(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "l2vpn vpls": { "192.168.201.1": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements["l2vpn vpls"], 1)
+	require.Len(t, announcements["l2vpn vpls"]["192.168.201.1"].Routes, 1)
+	vpls := announcements["l2vpn vpls"]["192.168.201.1"].Routes[0].VPLS
+	require.Equal(t, "192.168.201.1:123", vpls.RD)
+	require.Equal(t, 5, vpls.Endpoint)
+	require.Equal(t, 10702, vpls.Base)
+	require.Equal(t, 1, vpls.Offset)
+	require.Equal(t, 8, vpls.Size)
+}
+
+func TestL2VPNVplsWithdraw(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "withdraw": { "l2vpn vpls": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	w := evt.GetWithdrawals()
+	require.Len(t, w["l2vpn vpls"].Routes, 1)
+	require.Equal(t, "192.168.201.1:123", w["l2vpn vpls"].Routes[0].VPLS.RD)
+	require.Equal(t, 10702, w["l2vpn vpls"].Routes[0].VPLS.Base)
+}
+
+func TestIPv4MplsVPNAnnounce(t *testing.T) {
+	var testString = `{ "exabgp": "4.2.4", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "as-path": [ 100, 500 ], "local-preference": 100, "originator-id": "10.0.99.12", "extended-community": [ { "value": 144115188075855872, "string": "target:0:0" } ] }, "announce": { "ipv4 mpls-vpn": { "10.0.99.12": [ { "label": [ [ 110, 1761 ] ], "rd": "63333:100", "nlri": "128.0.64.0/18" } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements["ipv4 mpls-vpn"], 1)
+	require.Len(t, announcements["ipv4 mpls-vpn"]["10.0.99.12"].Routes, 1)
+	route := announcements["ipv4 mpls-vpn"]["10.0.99.12"].Routes[0]
+	require.Equal(t, "128.0.64.0/18", route.NLRI)
+	require.Equal(t, "63333:100", route.RD)
+	require.Equal(t, []int{110}, route.Labels)
+	require.Equal(t, []string{"0:0"}, route.RouteTargets())
+}
+
+func TestIPv6MplsVPNWithdraw(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "2001::1", "peer": "2001::2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv6 mpls-vpn": [ { "label": [ 1000 ], "rd": "65000:1", "nlri": "2001:db8:1000::/64" } ] } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	w := evt.GetWithdrawals()
+	require.Len(t, w["ipv6 mpls-vpn"].Routes, 1)
+	require.Equal(t, "2001:db8:1000::/64", w["ipv6 mpls-vpn"].Routes[0].NLRI)
+	require.Equal(t, "65000:1", w["ipv6 mpls-vpn"].Routes[0].RD)
+	require.Equal(t, []int{1000}, w["ipv6 mpls-vpn"].Routes[0].Labels)
+}
+
+func TestIPv4NLRIMplsAnnounce(t *testing.T) {
+	var testString = `{ "exabgp": "4.2.4", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 nlri-mpls": { "192.168.1.2": [ { "label": [ [ 100, 1601 ] ], "nlri": "10.0.0.0/24" } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements["ipv4 nlri-mpls"]["192.168.1.2"].Routes, 1)
+	route := announcements["ipv4 nlri-mpls"]["192.168.1.2"].Routes[0]
+	require.Equal(t, "10.0.0.0/24", route.NLRI)
+	require.Equal(t, []int{100}, route.Labels)
+	require.Empty(t, route.RD)
+}
+
+func TestIPv4MulticastAnnounceWithdraw(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 multicast": { "192.168.1.2": [ "239.1.0.0/16", { "nlri": "239.2.0.0/16" } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	announcements := evt.GetAnnouncements()
+	routes := announcements["ipv4 multicast"]["192.168.1.2"].Routes
+	require.Len(t, routes, 2)
+	require.Equal(t, "239.1.0.0/16", routes[0].NLRI)
+	require.Equal(t, "239.2.0.0/16", routes[1].NLRI)
+	require.Empty(t, routes[0].Labels)
+
+	testString = `{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 multicast": [ "239.1.0.0/16" ] } } } } }`
+	evt, err = ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	w := evt.GetWithdrawals()
+	require.Equal(t, []string{"239.1.0.0/16"}, w["ipv4 multicast"].NLRI())
+}
+
+func TestIPv4AddPathAnnounce(t *testing.T) {
+	var testString = `{ "exabgp": "4.2.4", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.10": [ { "path-information": "0.0.0.1", "nlri": "10.0.0.0/24" }, { "path-information": "0.0.0.2", "nlri": "10.0.0.0/24" } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	announcements := evt.GetAnnouncements()
+	// the path-information must not be mistaken for another prefix
+	require.Equal(t, []string{"10.0.0.0/24", "10.0.0.0/24"}, announcements["ipv4 unicast"]["192.168.1.10"].NLRI())
+	require.Equal(t, "0.0.0.1", announcements["ipv4 unicast"]["192.168.1.10"].Routes[0].PathID)
+	require.Equal(t, "0.0.0.2", announcements["ipv4 unicast"]["192.168.1.10"].Routes[1].PathID)
+}
+
+func TestIsMessage(t *testing.T) {
+	tc := map[string]struct {
+		line    string
+		message bool
+	}{
+		"keepalive": {`{ "exabgp": "4.0.1", "time": 1554987385.7035308, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 5, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive" } }`, true},
+		"refresh":   {`{ "exabgp": "4.2.4", "time": 1554987385.7035308, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 6, "type": "refresh", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "route-refresh": { "afi": "ipv4", "safi": "unicast", "subtype": "request" } } }`, true},
+		"state":     {`{ "exabgp": "4.0.1", "time": 1554851049.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`, false},
+		"signal":    {`{ "exabgp": "4.0.1", "time": 1554987385.7035308, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 7, "type": "signal", "name": "SIGUSR1", "code": 10 }`, false},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			evt, err := ParseEvent([]byte(test.line))
+			require.NoError(t, err)
+			require.Equal(t, test.message, evt.IsMessage())
 		})
 	}
 }
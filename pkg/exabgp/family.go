@@ -0,0 +1,139 @@
+package exabgp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages"
+)
+
+// FamilyDecoder decodes the json list of nlri for a single family in an
+// update message into routes. Only the nlri specific fields of the routes are
+// set, the family, next-hop, attributes and peer are filled in by the caller.
+type FamilyDecoder func(data json.RawMessage) ([]*Route, error)
+
+var (
+	families      = make(map[string]FamilyDecoder)
+	familiesMutex sync.RWMutex
+)
+
+// RegisterFamily registers the decoder for an "<afi> <safi>" family.
+// Registering a family a second time replaces its decoder.
+func RegisterFamily(family string, decoder FamilyDecoder) {
+	familiesMutex.Lock()
+	defer familiesMutex.Unlock()
+	families[family] = decoder
+}
+
+// Families returns the sorted list of all registered families
+func Families() []string {
+	familiesMutex.RLock()
+	defer familiesMutex.RUnlock()
+	fs := make([]string, 0, len(families))
+	for f := range families {
+		fs = append(fs, f)
+	}
+	sort.Strings(fs)
+	return fs
+}
+
+func familyDecoder(family string) (FamilyDecoder, bool) {
+	familiesMutex.RLock()
+	defer familiesMutex.RUnlock()
+	d, ok := families[family]
+	return d, ok
+}
+
+func init() {
+	for _, family := range []string{
+		"ipv4 unicast", "ipv6 unicast",
+		"ipv4 multicast", "ipv6 multicast",
+		"ipv4 nlri-mpls", "ipv6 nlri-mpls",
+		"ipv4 mpls-vpn", "ipv6 mpls-vpn",
+	} {
+		RegisterFamily(family, decodePrefixes)
+	}
+	RegisterFamily("ipv4 flow", decodeIPv4Flows)
+	RegisterFamily("ipv6 flow", decodeIPv6Flows)
+	RegisterFamily("l2vpn vpls", decodeVPLS)
+}
+
+// decodePrefixes handles all the prefix based families, the label and rd are
+// only set for the labelled ones
+func decodePrefixes(data json.RawMessage) ([]*Route, error) {
+	var nlris []messages.NLRIMessage
+	if err := json.Unmarshal(data, &nlris); err != nil {
+		return nil, fmt.Errorf("unable to parse routes: %s", string(data))
+	}
+	routes := make([]*Route, 0, len(nlris))
+	for _, n := range nlris {
+		routes = append(routes, &Route{
+			NLRI:   n.NLRI,
+			PathID: n.PathInformation,
+			RD:     n.RD,
+			Labels: n.Label,
+		})
+	}
+	return routes, nil
+}
+
+// flows have no single nlri so they are keyed by their string representation
+func decodeIPv4Flows(data json.RawMessage) ([]*Route, error) {
+	var flows []messages.IPv4FlowMessage
+	if err := json.Unmarshal(data, &flows); err != nil {
+		return nil, fmt.Errorf("unable to parse flows: %s", string(data))
+	}
+	routes := make([]*Route, 0, len(flows))
+	for _, f := range flows {
+		routes = append(routes, &Route{
+			NLRI: f.String,
+			Flow: &Flow{
+				Destination:     f.DestinationIPv4,
+				Source:          f.SourceIPv4,
+				Protocol:        f.Protocol,
+				DestinationPort: f.DestinationPort,
+				SourcePort:      f.SourcePort,
+			},
+		})
+	}
+	return routes, nil
+}
+
+func decodeIPv6Flows(data json.RawMessage) ([]*Route, error) {
+	var flows []messages.IPv6FlowMessage
+	if err := json.Unmarshal(data, &flows); err != nil {
+		return nil, fmt.Errorf("unable to parse flows: %s", string(data))
+	}
+	routes := make([]*Route, 0, len(flows))
+	for _, f := range flows {
+		routes = append(routes, &Route{
+			NLRI: f.String,
+			Flow: &Flow{
+				Destination:     f.DestinationIPv6,
+				Source:          f.SourceIPv6,
+				Protocol:        f.NextHeader,
+				DestinationPort: f.DestinationPort,
+				SourcePort:      f.SourcePort,
+			},
+		})
+	}
+	return routes, nil
+}
+
+func decodeVPLS(data json.RawMessage) ([]*Route, error) {
+	var vpls []messages.L2VPNVplsMessage
+	if err := json.Unmarshal(data, &vpls); err != nil {
+		return nil, fmt.Errorf("unable to parse vpls: %s", string(data))
+	}
+	routes := make([]*Route, 0, len(vpls))
+	for _, v := range vpls {
+		block := &VPLS{RD: v.RD, Endpoint: v.Endpoint, Base: v.Base, Offset: v.Offset, Size: v.Size}
+		routes = append(routes, &Route{
+			NLRI: block.NLRI(),
+			VPLS: block,
+		})
+	}
+	return routes, nil
+}
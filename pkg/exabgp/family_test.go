@@ -0,0 +1,60 @@
+package exabgp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFamilies(t *testing.T) {
+	fs := Families()
+	for _, family := range []string{"ipv4 unicast", "ipv6 unicast", "ipv4 flow", "ipv6 flow", "l2vpn vpls", "ipv4 mpls-vpn", "ipv4 nlri-mpls", "ipv4 multicast"} {
+		require.Contains(t, fs, family)
+	}
+}
+
+func TestUnknownFamily(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.2": [ "192.168.88.0/24" ] }, "ipv4 flow-vpn": { "no-nexthop": [ { "rd": "65000:1", "destination-ipv4": [ "10.0.0.1/32" ] } ] } } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Equal(t, []string{"ipv4 flow-vpn"}, evt.UnknownFamilies)
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements, 1)
+	require.Equal(t, []string{"192.168.88.0/24"}, announcements["ipv4 unicast"]["192.168.1.2"].NLRI())
+}
+
+func TestRegisterFamily(t *testing.T) {
+	RegisterFamily("ipv4 flow-vpn", func(data json.RawMessage) ([]*Route, error) {
+		return []*Route{{NLRI: string(data)}}, nil
+	})
+	defer func() {
+		familiesMutex.Lock()
+		delete(families, "ipv4 flow-vpn")
+		familiesMutex.Unlock()
+	}()
+
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 flow-vpn": [ "rule" ] } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Empty(t, evt.UnknownFamilies)
+	w := evt.GetWithdrawals()
+	require.Len(t, w["ipv4 flow-vpn"].Routes, 1)
+	require.Equal(t, "ipv4 flow-vpn", w["ipv4 flow-vpn"].Routes[0].Family)
+	require.Equal(t, `[ "rule" ]`, w["ipv4 flow-vpn"].Routes[0].NLRI)
+}
+
+func TestDecodeError(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "announce": { "ipv4 unicast": { "192.168.1.2": [ "192.168.88.0/24" ] }, "l2vpn vpls": { "192.168.201.1": [ "192.168.201.1:123" ] } }, "withdraw": { "ipv4 unicast": [ "192.168.89.0/24" ], "l2vpn vpls": [ "192.168.201.1:123" ] } } } } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Len(t, evt.FamilyErrors, 1)
+	require.Error(t, evt.FamilyErrors["l2vpn vpls"])
+	// the routes of the other families are kept
+	announcements := evt.GetAnnouncements()
+	require.Len(t, announcements, 1)
+	require.Equal(t, []string{"192.168.88.0/24"}, announcements["ipv4 unicast"]["192.168.1.2"].NLRI())
+	withdrawals := evt.GetWithdrawals()
+	require.Len(t, withdrawals, 1)
+	require.Len(t, withdrawals["ipv4 unicast"].Routes, 1)
+}
@@ -37,6 +37,9 @@ func (e *Timestamp) UnmarshalJSON(data []byte) error {
 type JSONEvent struct {
 	BaseEvent
 	Neighbor `json:"neighbor"`
+	// Shutdown is the notification exabgp sends when it exits, it is not
+	// tied to a neighbor: { "type": "notification", "notification": "shutdown" }
+	Shutdown string `json:"notification"`
 }
 
 // BaseEvent is represents the common data in all messages
@@ -60,15 +63,21 @@ type Neighbor struct {
 		Local int `json:"local"`
 		Peer  int `json:"peer"`
 	} `json:"asn"`
-	Direction    string      `json:"direction"`
-	State        string      `json:"state"`
-	Reason       string      `json:"reason"`
-	Notification interface{} `json:"notification"`
+	Direction    string               `json:"direction"`
+	State        string               `json:"state"`
+	Reason       string               `json:"reason"`
+	Notification *NotificationMessage `json:"notification"`
+	Open         *OpenMessage         `json:"open"`
 	Message      struct {
 		Update UpdateMessageFull `json:"update"`
+		// End-of-RIB markers are update messages but exabgp reports them
+		// next to the update rather than in it
+		EOR *EORMessage `json:"eor"`
 	} `json:"message"`
-	Name string `json:"name"`
-	Code string `json:"code"`
+	// Name and Code are set for signal events, exabgp versions disagree on
+	// whether the code is a number or a string
+	Name string      `json:"name"`
+	Code json.Number `json:"code"`
 }
 
 // NotificationMessage represents a notification message
@@ -78,6 +87,24 @@ type NotificationMessage struct {
 	Data    string `json:"data"`
 }
 
+// OpenMessage represents an open message
+// the capabilities are keyed by their code for received opens and by their
+// name for sent ones, depending on the exabgp version
+type OpenMessage struct {
+	Version      int                          `json:"version"`
+	ASN          int                          `json:"asn"`
+	HoldTime     int                          `json:"hold_time"`
+	RouterID     string                       `json:"router_id"`
+	Capabilities map[string]CapabilityMessage `json:"capabilities"`
+}
+
+// CapabilityMessage represents a capability advertised in an open message
+// { "name": "multiprotocol", "families": [ "ipv4/unicast" ] }
+type CapabilityMessage struct {
+	Name     string   `json:"name"`
+	Families []string `json:"families"`
+}
+
 // UpdateMessage is a bgp update message
 type UpdateMessage struct {
 	Attribute `json:"attribute"`
@@ -88,26 +115,15 @@ type UpdateMessage struct {
 // UpdateMessageFull represents an update message
 type UpdateMessageFull struct {
 	Attribute `json:"attribute"`
-	Announce  struct {
-		// messages are different between compact and non-compact mode
-		// compact: { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } }
-		// non-compact: "ipv4 unicast": [ { "nlri": "192.168.88.0/24" } ] } } } } }
-		IPv4Unicast map[string][]interface{}      `json:"ipv4 unicast"`
-		IPv4Flow    IPv4FlowAnnounceMessage       `json:"ipv4 flow"`
-		IPv6Unicast map[string][]interface{}      `json:"ipv6 unicast"`
-		IPv6Flow    IPv6FlowAnnounceMessage       `json:"ipv6 flow"`
-		L2VPNVpls   map[string][]L2VPNVplsMessage `json:"l2vpn vpls"`
-	} `json:"announce"`
-	Withdraw struct {
-		// compact: { "ipv4 unicast": { [ "192.168.88.2/32" ] } }
-		// non-compact: "ipv4 unicast": [ { "nlri": "192.168.88.0/24" } ] } } } } }
-		IPv4Unicast []interface{}             `json:"ipv4 unicast"`
-		IPv4Flow    []IPv4FlowWithdrawMessage `json:"ipv4 flow"`
-		IPv6Unicast []interface{}             `json:"ipv6 unicast"`
-		IPv6Flow    []IPv6FlowWithdrawMessage `json:"ipv6 flow"`
-		L2VPNVpls   []L2VPNVplsMessage        `json:"l2vpn vpls"`
-	} `json:"withdraw"`
-	EOR EORMessage `json:"eor"`
+	// the nlri are kept raw keyed by "<afi> <safi>" family (and next-hop for
+	// announcements) since their format depends on the family.
+	// messages are also different between compact and non-compact mode
+	// compact: { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } }
+	// non-compact: "ipv4 unicast": [ { "nlri": "192.168.88.0/24" } ] } } } } }
+	Announce map[string]map[string]json.RawMessage `json:"announce"`
+	// compact: { "ipv4 unicast": [ "192.168.88.2/32" ] }
+	// non-compact: "ipv4 unicast": [ { "nlri": "192.168.88.0/24" } ] } } } } }
+	Withdraw map[string]json.RawMessage `json:"withdraw"`
 }
 
 // EORMessage represents an End-of-RIB message
@@ -136,6 +152,9 @@ type IPv4FlowAnnounceMessage map[string][]IPv4FlowMessage
 type IPv4FlowMessage struct {
 	DestinationIPv4 []string `json:"destination-ipv4"`
 	SourceIPv4      []string `json:"source-ipv4"`
+	Protocol        []string `json:"protocol"`
+	DestinationPort []string `json:"destination-port"`
+	SourcePort      []string `json:"source-port"`
 	String          string   `json:"string"`
 }
 
@@ -143,6 +162,9 @@ type IPv4FlowMessage struct {
 type IPv4FlowWithdrawMessage struct {
 	DestinationIPv4 []string `json:"destination-ipv4"`
 	SourceIPv4      []string `json:"source-ipv4"`
+	Protocol        []string `json:"protocol"`
+	DestinationPort []string `json:"destination-port"`
+	SourcePort      []string `json:"source-port"`
 	String          string   `json:"string"`
 }
 
@@ -159,6 +181,9 @@ type IPv6FlowAnnounceMessage map[string][]IPv6FlowMessage
 type IPv6FlowMessage struct {
 	DestinationIPv6 []string `json:"destination-ipv6"`
 	SourceIPv6      []string `json:"source-ipv6"`
+	NextHeader      []string `json:"next-header"`
+	DestinationPort []string `json:"destination-port"`
+	SourcePort      []string `json:"source-port"`
 	String          string   `json:"string"`
 }
 
@@ -166,6 +191,9 @@ type IPv6FlowMessage struct {
 type IPv6FlowWithdrawMessage struct {
 	DestinationIPv6 []string `json:"destination-ipv6"`
 	SourceIPv6      []string `json:"source-ipv6"`
+	NextHeader      []string `json:"next-header"`
+	DestinationPort []string `json:"destination-port"`
+	SourcePort      []string `json:"source-port"`
 	String          string   `json:"string"`
 }
 
@@ -184,22 +212,86 @@ type L2VPNVplsMessage struct {
 	*/
 	RD       string `json:"rd"`
 	Endpoint int    `json:"endpoint"`
+	Base     int    `json:"base"`
 	Offset   int    `json:"offset"`
 	Size     int    `json:"size"`
 }
 
+// NLRIMessage represents a single nlri in any of the prefix based families
+// (unicast, multicast, nlri-mpls and mpls-vpn). The label and rd are only
+// present for the labelled families, the path-information only when add-path
+// has been negotiated with the peer.
+// compact: "192.168.88.0/24"
+// non-compact: { "nlri": "192.168.88.0/24" }
+// add-path: { "path-information": "0.0.0.1", "nlri": "192.168.88.0/24" }
+// labelled: { "label": [ [ 110, 1761 ] ], "rd": "63333:100", "nlri": "128.0.64.0/18" }
+type NLRIMessage struct {
+	NLRI            string `json:"nlri"`
+	PathInformation string `json:"path-information"`
+	Label           Labels `json:"label"`
+	RD              string `json:"rd"`
+}
+
+// UnmarshalJSON decodes both the compact and non-compact nlri forms
+func (n *NLRIMessage) UnmarshalJSON(data []byte) error {
+	var nlri string
+	if err := json.Unmarshal(data, &nlri); err == nil {
+		*n = NLRIMessage{NLRI: nlri}
+		return nil
+	}
+	// avoid recursing back into this method
+	type nlriMessage NLRIMessage
+	var m nlriMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("unable to parse nlri: %s", string(data))
+	}
+	*n = NLRIMessage(m)
+	return nil
+}
+
+// Labels represents the mpls label stack of a labelled nlri
+// depending on the exabgp version each label is either a bare number
+// or a pair of the label and its raw encoding: [ 110 ] or [ [ 110, 1761 ] ]
+type Labels []int
+
+// UnmarshalJSON decodes either form of the label stack
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("unable to parse labels: %s", string(data))
+	}
+	labels := Labels{}
+	for _, r := range raw {
+		var label int
+		if err := json.Unmarshal(r, &label); err == nil {
+			labels = append(labels, label)
+			continue
+		}
+		var pair []int
+		if err := json.Unmarshal(r, &pair); err != nil || len(pair) == 0 {
+			return fmt.Errorf("unable to parse label: %s", string(r))
+		}
+		labels = append(labels, pair[0])
+	}
+	*l = labels
+	return nil
+}
+
 // Attribute represent BGP attributes for a message
 type Attribute struct {
-	Med               int64 `json:"med"`
-	ExtendedCommunity []struct {
-		Value  json.Number `json:"value"`
-		String string      `json:"string"`
-	} `json:"extended-community"`
-	Community         [][]int  `json:"community"`
-	ASPath            []int    `json:"as-path"`
-	ConfederationPath []int    `json:"confederation-path"`
-	OriginatorID      string   `json:"originator-id"`
-	LocalPreference   int      `json:"local-preference"`
-	Origin            string   `json:"origin"`
-	ClusterList       []string `json:"cluster-list"`
+	Med               int64               `json:"med"`
+	ExtendedCommunity []ExtendedCommunity `json:"extended-community"`
+	Community         [][]int             `json:"community"`
+	ASPath            []int               `json:"as-path"`
+	ConfederationPath []int               `json:"confederation-path"`
+	OriginatorID      string              `json:"originator-id"`
+	LocalPreference   int                 `json:"local-preference"`
+	Origin            string              `json:"origin"`
+	ClusterList       []string            `json:"cluster-list"`
+}
+
+// ExtendedCommunity represents a BGP extended community
+type ExtendedCommunity struct {
+	Value  json.Number `json:"value"`
+	String string      `json:"string"`
 }
@@ -0,0 +1,213 @@
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a neighbor starts with its header line, followed by sections of aligned
+// columns, each with its own header line:
+// Neighbor <ip>
+// Session: local <ip>, state <state>, up for|down for <timedelta>
+// Setup: AS, ID and hold-time, local and remote
+// Capability and Families: <name>: <local> <remote> [<add-path>]
+// Message Statistic: <type>: <sent> <received>
+var rxNeighborHeader = `^Neighbor (?P<peer_ip>\S+)$`
+
+// sections of a neighbor, by the first words of their header line
+var neighborSections = []string{"Session", "Setup", "Capability", "Families", "Message Statistic"}
+
+// NotAvailable is the value exabgp shows for what it doesn't know yet, like
+// the settings of a peer it never received an open message from
+const NotAvailable = "n/a"
+
+// NeighborsFromBytes takes the output of show neighbor extensive and returns
+// a collection of Neighbor
+func NeighborsFromBytes(b []byte) ([]*Neighbor, error) {
+	var neighbors []*Neighbor
+	var neighbor *Neighbor
+	var section string
+	re := regexp.MustCompile(rxNeighborHeader)
+	reader := bufio.NewReader(bytes.NewReader(b))
+	for {
+		l, _, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		line := strings.TrimSpace(string(l))
+		if line == "" {
+			continue
+		}
+		if matches := re.FindStringSubmatch(line); len(matches) != 0 {
+			neighbor = newNeighbor(matches[1])
+			neighbors = append(neighbors, neighbor)
+			section = ""
+			continue
+		}
+		if neighbor == nil {
+			return neighbors, fmt.Errorf("unable to parse line, no neighbor: %s", line)
+		}
+		if s := neighborSection(line); s != "" {
+			section = s
+			continue
+		}
+		if err := neighbor.parseLine(section, line); err != nil {
+			return neighbors, err
+		}
+	}
+	return neighbors, nil
+}
+
+func neighborSection(line string) string {
+	for _, s := range neighborSections {
+		if strings.HasPrefix(line, s+" ") {
+			return s
+		}
+	}
+	return ""
+}
+
+func newNeighbor(peer string) *Neighbor {
+	return &Neighbor{
+		PeerAddress:  peer,
+		Capabilities: make(map[string]NeighborSetting),
+		Families:     make(map[string]NeighborFamily),
+		Messages:     make(map[string]NeighborMessages),
+	}
+}
+
+func (n *Neighbor) parseLine(section string, line string) error {
+	fields := strings.Fields(line)
+	switch section {
+	case "Session":
+		// the durations are python timedeltas which may contain spaces
+		switch {
+		// the rest of the neighbor is still good when they can't be parsed
+		case strings.HasPrefix(line, "up for "):
+			n.Uptime, n.UptimeError = parseUptime(strings.TrimPrefix(line, "up for "))
+		case strings.HasPrefix(line, "down for "):
+			n.DownTime, n.UptimeError = parseUptime(strings.TrimPrefix(line, "down for "))
+		case len(fields) == 2 && fields[0] == "local":
+			n.LocalAddress = fields[1]
+		case len(fields) == 2 && fields[0] == "state":
+			n.State = fields[1]
+		}
+	case "Setup":
+		if len(fields) != 3 {
+			return fmt.Errorf("unable to parse setup: %s", line)
+		}
+		switch fields[0] {
+		case "AS":
+			n.LocalAS, n.PeerAS = fields[1], fields[2]
+		case "ID":
+			n.LocalRouterID, n.PeerRouterID = fields[1], fields[2]
+		case "hold-time":
+			n.LocalHoldTime, n.PeerHoldTime = fields[1], fields[2]
+		}
+	case "Capability", "Families", "Message Statistic":
+		// the names end with a colon and may contain spaces (ipv4 unicast:)
+		i := strings.Index(line, ":")
+		if i == -1 {
+			return fmt.Errorf("unable to parse %s: %s", strings.ToLower(section), line)
+		}
+		name := line[:i]
+		values := strings.Fields(line[i+1:])
+		if len(values) < 2 {
+			return fmt.Errorf("unable to parse %s: %s", strings.ToLower(section), line)
+		}
+		switch section {
+		case "Capability":
+			n.Capabilities[name] = NeighborSetting{Local: values[0], Remote: values[1]}
+		case "Families":
+			n.Families[name] = NeighborFamily{
+				NeighborSetting: NeighborSetting{Local: values[0], Remote: values[1]},
+				AddPath:         strings.Join(values[2:], " "),
+			}
+		default:
+			sent, err := strconv.Atoi(values[0])
+			if err != nil {
+				return err
+			}
+			received, err := strconv.Atoi(values[1])
+			if err != nil {
+				return err
+			}
+			n.Messages[name] = NeighborMessages{Sent: sent, Received: received}
+		}
+	default:
+		return fmt.Errorf("unable to parse line: %s", line)
+	}
+	return nil
+}
+
+// Summary returns the neighbor as show neighbor summary would, which counts
+// the update messages
+func (n *Neighbor) Summary() *NeighborSummary {
+	ns := &NeighborSummary{
+		IPAddress: n.PeerAddress,
+		AS:        n.PeerAS,
+		Status:    "down",
+		State:     n.State,
+		Sent:      n.Messages["update"].Sent,
+		Received:  n.Messages["update"].Received,
+		// the down time gives the state change of down peers
+		UptimeError: n.UptimeError,
+	}
+	if n.State == "established" {
+		ns.Status = "up"
+		ns.Uptime = n.Uptime
+	}
+	return ns
+}
+
+// Neighbor represents a neighbor as shown by show neighbor extensive, the
+// values exabgp doesn't know are NotAvailable
+type Neighbor struct {
+	PeerAddress  string
+	LocalAddress string
+	State        string
+	// Uptime is how long the session has been established for
+	Uptime time.Duration
+	// DownTime is how long the session has been down for
+	DownTime time.Duration
+	// UptimeError is set when the up or down time could not be parsed, it is
+	// then unknown
+	UptimeError   error
+	LocalAS       string
+	PeerAS        string
+	LocalRouterID string
+	PeerRouterID  string
+	LocalHoldTime string
+	PeerHoldTime  string
+	// Capabilities and Families are keyed by name ("asn4", "ipv4 unicast")
+	Capabilities map[string]NeighborSetting
+	Families     map[string]NeighborFamily
+	// Messages is keyed by message type ("update", "keepalive", ..., "total")
+	Messages map[string]NeighborMessages
+}
+
+// NeighborSetting tells whether something is enabled on each side of the
+// session: "enabled", "disabled" or NotAvailable
+type NeighborSetting struct {
+	Local  string
+	Remote string
+}
+
+// NeighborFamily is a family of the session and whether add-path is used
+// for it: "disabled", "send", "receive" or "send/receive"
+type NeighborFamily struct {
+	NeighborSetting
+	AddPath string
+}
+
+// NeighborMessages counts the messages sent to and received from a peer
+type NeighborMessages struct {
+	Sent     int
+	Received int
+}
@@ -0,0 +1,87 @@
+package text
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testNeighborDataFile = filepath.Join("testdata", "neighbor-extensive.txt")
+
+func TestParseNeighborTestData(t *testing.T) {
+	file, err := os.ReadFile(testNeighborDataFile)
+	require.NoError(t, err)
+
+	neighbors, err := NeighborsFromBytes(file)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(neighbors))
+
+	n := neighbors[0]
+	require.Equal(t, "127.0.0.1", n.PeerAddress)
+	require.Equal(t, "127.0.0.1", n.LocalAddress)
+	require.Equal(t, "established", n.State)
+	require.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, n.Uptime)
+	require.Equal(t, "64496", n.LocalAS)
+	require.Equal(t, "64497", n.PeerAS)
+	require.Equal(t, "1.1.1.1", n.LocalRouterID)
+	require.Equal(t, "2.2.2.2", n.PeerRouterID)
+	require.Equal(t, "180", n.LocalHoldTime)
+	require.Equal(t, "90", n.PeerHoldTime)
+	require.Equal(t, 7, len(n.Capabilities))
+	require.Equal(t, NeighborSetting{Local: "disabled", Remote: "enabled"}, n.Capabilities["graceful-restart"])
+	require.Equal(t, 3, len(n.Families))
+	require.Equal(t, NeighborSetting{Local: "enabled", Remote: "disabled"}, n.Families["ipv6 unicast"].NeighborSetting)
+	require.Equal(t, "send/receive", n.Families["ipv4 flow"].AddPath)
+	require.Equal(t, 6, len(n.Messages))
+	require.Equal(t, NeighborMessages{Sent: 1834, Received: 1829}, n.Messages["keepalive"])
+	require.Equal(t, NeighborMessages{Sent: 1880, Received: 1833}, n.Messages["total"])
+}
+
+func TestParseNeighborDown(t *testing.T) {
+	file, err := os.ReadFile(testNeighborDataFile)
+	require.NoError(t, err)
+
+	neighbors, err := NeighborsFromBytes(file)
+	require.NoError(t, err)
+
+	n := neighbors[1]
+	require.Equal(t, "idle", n.State)
+	require.Equal(t, time.Duration(0), n.Uptime)
+	require.Equal(t, 42*time.Second, n.DownTime)
+	require.Equal(t, NotAvailable, n.PeerRouterID)
+	require.Equal(t, NotAvailable, n.PeerHoldTime)
+	require.Equal(t, NotAvailable, n.Capabilities["asn4"].Remote)
+	require.Equal(t, NeighborMessages{Sent: 1, Received: 0}, n.Messages["notification"])
+}
+
+func TestNeighborSummary(t *testing.T) {
+	file, err := os.ReadFile(testNeighborDataFile)
+	require.NoError(t, err)
+
+	neighbors, err := NeighborsFromBytes(file)
+	require.NoError(t, err)
+
+	up := neighbors[0].Summary()
+	require.Equal(t, &NeighborSummary{IPAddress: "127.0.0.1", AS: "64497", Status: "up", State: "established", Sent: 45, Received: 3, Uptime: 26*time.Hour + 3*time.Minute + 4*time.Second}, up)
+	down := neighbors[1].Summary()
+	require.Equal(t, &NeighborSummary{IPAddress: "192.168.1.2", AS: "64496", Status: "down", State: "idle"}, down)
+}
+
+func TestParseNeighborInvalidUptime(t *testing.T) {
+	neighbors, err := NeighborsFromBytes([]byte("Neighbor 127.0.0.1\n\n    Session                         Local\n   state                    established\n   up for                          soon\n"))
+	require.NoError(t, err)
+	require.Len(t, neighbors, 1)
+	require.Error(t, neighbors[0].UptimeError)
+	require.Equal(t, "established", neighbors[0].State)
+	require.Error(t, neighbors[0].Summary().UptimeError)
+}
+
+func TestParseNeighborInvalid(t *testing.T) {
+	_, err := NeighborsFromBytes([]byte("   state                    established\n"))
+	require.Error(t, err)
+	_, err = NeighborsFromBytes([]byte("Neighbor 127.0.0.1\n\n    Message Statistic                Sent        Received\n   update:                        lots               3\n"))
+	require.Error(t, err)
+}
@@ -10,10 +10,43 @@ import (
 	"strings"
 )
 
-// line format:
+// line format, the same for adj-rib in and out:
 // neighbor <string> local-ip <string> local-as <int> peer-as <int> router-id <string> family-allowed in-open <afi> <safi> <details>
-var rxParseRIBLine = `^neighbor (?P<neighbor>\S+) local-ip (?P<local_ip>\S+) local-as (?P<local_as>\d+) peer-as (?P<peer_as>\d+) router-id (?P<router_id>\S+) family-allowed in-open (?P<afi>\S+) (?P<safi>\S+) (?P<details>.*)$`
-var rxParseUnicast = `^(?P<nlri>\S+) next-hop (?P<next_hop>\S+)(| (?P<attributes>.*))$`
+// with multi-session the families are listed instead of in-open: family-allowed ipv4-unicast/ipv6-unicast
+var rxParseRIBLine = `^neighbor (?P<neighbor>\S+) local-ip (?P<local_ip>\S+) local-as (?P<local_as>\d+) peer-as (?P<peer_as>\d+) router-id (?P<router_id>\S+) family-allowed \S+ (?P<afi>\S+) (?P<safi>\S+) (?P<details>.*)$`
+var rxParseUnicast = `^(?P<nlri>\S+)(?: path-information (?P<path_id>\S+))? next-hop (?P<next_hop>\S+)(| (?P<attributes>.*))$`
+
+// prefix based families (unicast, multicast, nlri-mpls, mpls-vpn) share a format
+// <nlri> [path-information <string>] [label <int>] next-hop <string> [rd <string>] <attributes>
+// the label can also be a list: label [ <int> <int> ]
+var rxParsePrefix = `^(?P<nlri>\S+) (?P<details>.*)$`
+var rxParsePrefixLabel = `(?:^|\s+)label (?:\[ (?P<labels>[^\]]+) \]|(?P<label>\d+))`
+var rxParsePrefixPathID = `(?:^|\s+)path-information (?P<path_id>\S+)`
+var rxParsePrefixRD = `(?:^|\s+)rd (?P<rd>\S+)`
+var rxParsePrefixNextHop = `(?:^|\s+)next-hop (?P<next_hop>\S+)`
+var rxParseFlow = `^flow (?P<flow>.*)$`
+
+// vpls endpoint <int> base <int> offset <int> size <int> rd <string> next-hop <string> <attributes>
+// the position of the route distinguisher differs between exabgp versions so each field is matched on its own
+var rxParseVPLS = `^vpls (?P<vpls>.*)$`
+var rxParseVPLSRD = `(?:^|\s+)rd (?P<rd>\S+)`
+var rxParseVPLSEndpoint = `(?:^|\s+)endpoint (?P<endpoint>\d+)`
+var rxParseVPLSBase = `(?:^|\s+)base (?P<base>\d+)`
+var rxParseVPLSOffset = `(?:^|\s+)offset (?P<offset>\d+)`
+var rxParseVPLSSize = `(?:^|\s+)size (?P<size>\d+)`
+var rxParseVPLSNextHop = `(?:^|\s+)next-hop (?P<next_hop>\S+)`
+
+// the attributes which may follow the match components of a flow
+var flowAttributes = map[string]bool{
+	"next-hop": true, "origin": true, "as-path": true, "med": true, "local-preference": true,
+	"atomic-aggregate": true, "aggregator": true, "community": true, "extended-community": true,
+	"large-community": true, "originator-id": true, "cluster-list": true,
+}
+
+// flow match components are either a single value or a list of values
+// destination-port =3128
+// destination-port [ =80 =443 ]
+var rxParseFlowComponent = `(?:^|\s+)%s (?:\[ (?P<values>[^\]]+) \]|(?P<value>\S+))`
 
 // regexp for parsing attributes
 var rxParseAttributeMed = `(?:^|\s+)med (?P<med>\d+)`
@@ -129,6 +162,113 @@ func parseUnicastLine(s string) (map[string]string, error) {
 	return md, nil
 }
 
+func parseFlowComponent(s string, component string) string {
+	re := regexp.MustCompile(fmt.Sprintf(rxParseFlowComponent, regexp.QuoteMeta(component)))
+	match := re.FindStringSubmatch(s)
+	if len(match) == 0 {
+		return ""
+	}
+	if match[1] != "" {
+		return match[1]
+	}
+	return match[2]
+}
+
+func parseFlowLine(s string) (map[string]string, error) {
+	re := regexp.MustCompile(rxParseFlow)
+	matches := re.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("unable to parse line")
+	}
+	md := make(map[string]string)
+	for _, component := range []string{
+		"destination-ipv4", "source-ipv4", "destination-ipv6", "source-ipv6",
+		"protocol", "next-header", "destination-port", "source-port",
+	} {
+		md[component] = parseFlowComponent(matches[1], component)
+	}
+	md["extended_community"] = strings.Join(parseAttributes(matches[1]).ExtendedCommunity, " ")
+	md["rule"] = parseFlowRule(matches[1])
+	return md, nil
+}
+
+// parseFlowRule returns the match components of a flow, as exabgp shows the
+// rule in its json: flow destination-ipv4 10.0.0.1/32 protocol =tcp
+func parseFlowRule(s string) string {
+	fields := strings.Fields(s)
+	for i, f := range fields {
+		if flowAttributes[f] {
+			fields = fields[:i]
+			break
+		}
+	}
+	return "flow " + strings.Join(fields, " ")
+}
+
+func parsePrefixLine(s string) (map[string]string, error) {
+	re := regexp.MustCompile(rxParsePrefix)
+	matches := re.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("unable to parse line")
+	}
+	md := make(map[string]string)
+	md["nlri"] = matches[1]
+	for name, rx := range map[string]string{
+		"path_id":  rxParsePrefixPathID,
+		"rd":       rxParsePrefixRD,
+		"next_hop": rxParsePrefixNextHop,
+	} {
+		match := regexp.MustCompile(rx).FindStringSubmatch(matches[2])
+		if len(match) != 0 {
+			md[name] = match[1]
+		}
+	}
+	match := regexp.MustCompile(rxParsePrefixLabel).FindStringSubmatch(matches[2])
+	if len(match) != 0 {
+		md["labels"] = match[1] + match[2]
+	}
+	md["attributes"] = matches[2]
+	return md, nil
+}
+
+func routeTargets(a Attribute) []string {
+	targets := []string{}
+	for _, ec := range a.ExtendedCommunity {
+		if strings.HasPrefix(ec, "target:") {
+			targets = append(targets, strings.TrimPrefix(ec, "target:"))
+		}
+	}
+	return targets
+}
+
+func parseVPLSLine(s string) (map[string]string, error) {
+	re := regexp.MustCompile(rxParseVPLS)
+	matches := re.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("unable to parse line")
+	}
+	md := make(map[string]string)
+	for name, rx := range map[string]string{
+		"rd":       rxParseVPLSRD,
+		"endpoint": rxParseVPLSEndpoint,
+		"base":     rxParseVPLSBase,
+		"offset":   rxParseVPLSOffset,
+		"size":     rxParseVPLSSize,
+		"next_hop": rxParseVPLSNextHop,
+	} {
+		match := regexp.MustCompile(rx).FindStringSubmatch(matches[1])
+		if len(match) == 0 {
+			if name == "next_hop" {
+				continue
+			}
+			return nil, fmt.Errorf("unable to parse line: missing %s", name)
+		}
+		md[name] = match[1]
+	}
+	md["attributes"] = matches[1]
+	return md, nil
+}
+
 func parseRIBLine(s string) (map[string]string, error) {
 	md := make(map[string]string)
 	re := regexp.MustCompile(rxParseRIBLine)
@@ -213,6 +353,7 @@ func (m *RIBMessage) IPv4Unicast() (*IPv4UnicastAnnounceTextMessage, error) {
 		return nil, err
 	}
 	nm.NLRI = res["nlri"]
+	nm.PathID = res["path_id"]
 	nm.NextHop = res["next_hop"]
 	nm.Attributes = parseAttributes(res["attributes"])
 	return nm, nil
@@ -220,7 +361,22 @@ func (m *RIBMessage) IPv4Unicast() (*IPv4UnicastAnnounceTextMessage, error) {
 
 // IPv4Flow returns an ipv4 flow from a rib line
 func (m *RIBMessage) IPv4Flow() (*IPv4FlowAnnounceTextMessage, error) {
-	return nil, nil
+	if m.Family() != "ipv4 flow" {
+		return nil, fmt.Errorf("wrong entry family: %s", m.Family())
+	}
+	nm := &IPv4FlowAnnounceTextMessage{}
+	res, err := parseFlowLine(m.Details)
+	if err != nil {
+		return nil, err
+	}
+	nm.DestinationIPv4 = res["destination-ipv4"]
+	nm.SourceIPv4 = res["source-ipv4"]
+	nm.Protocol = res["protocol"]
+	nm.DestinationPort = res["destination-port"]
+	nm.SourcePort = res["source-port"]
+	nm.ExtendedCommunity = res["extended_community"]
+	nm.Rule = res["rule"]
+	return nm, nil
 }
 
 // IPv6Unicast returns an ipv6 unicast from a rib line
@@ -234,6 +390,7 @@ func (m *RIBMessage) IPv6Unicast() (*IPv6UnicastAnnounceTextMessage, error) {
 		return nil, err
 	}
 	nm.NLRI = res["nlri"]
+	nm.PathID = res["path_id"]
 	nm.NextHop = res["next_hop"]
 	nm.Attributes = parseAttributes(res["attributes"])
 	return nm, nil
@@ -241,7 +398,79 @@ func (m *RIBMessage) IPv6Unicast() (*IPv6UnicastAnnounceTextMessage, error) {
 
 // IPv6Flow returns an ipv6 flow from a rib line
 func (m *RIBMessage) IPv6Flow() (*IPv6FlowAnnounceTextMessage, error) {
-	return nil, nil
+	if m.Family() != "ipv6 flow" {
+		return nil, fmt.Errorf("wrong entry family: %s", m.Family())
+	}
+	nm := &IPv6FlowAnnounceTextMessage{}
+	res, err := parseFlowLine(m.Details)
+	if err != nil {
+		return nil, err
+	}
+	nm.DestinationIPv6 = res["destination-ipv6"]
+	nm.SourceIPv6 = res["source-ipv6"]
+	// ipv6 flows match on next-header rather than protocol
+	nm.Protocol = res["next-header"]
+	nm.DestinationPort = res["destination-port"]
+	nm.SourcePort = res["source-port"]
+	nm.ExtendedCommunity = res["extended_community"]
+	nm.Rule = res["rule"]
+	return nm, nil
+}
+
+// Route returns a prefix based route from a rib line. It handles every
+// family that shares the unicast format with an optional label and rd
+// (unicast, multicast, nlri-mpls and mpls-vpn)
+func (m *RIBMessage) Route() (*RouteAnnounceTextMessage, error) {
+	switch m.SAFI {
+	case "unicast", "multicast", "nlri-mpls", "mpls-vpn":
+	default:
+		return nil, fmt.Errorf("wrong entry family: %s", m.Family())
+	}
+	nm := &RouteAnnounceTextMessage{}
+	res, err := parsePrefixLine(m.Details)
+	if err != nil {
+		return nil, err
+	}
+	nm.NLRI = res["nlri"]
+	nm.PathID = res["path_id"]
+	for _, l := range strings.Fields(res["labels"]) {
+		if x, err := strconv.Atoi(l); err == nil {
+			nm.Labels = append(nm.Labels, x)
+		}
+	}
+	nm.NextHop = res["next_hop"]
+	nm.RouteDistinguisher = res["rd"]
+	nm.Attributes = parseAttributes(res["attributes"])
+	if m.SAFI == "mpls-vpn" {
+		if len(nm.Labels) == 0 {
+			return nil, fmt.Errorf("unable to parse line: missing label")
+		}
+		if nm.RouteDistinguisher == "" {
+			return nil, fmt.Errorf("unable to parse line: missing rd")
+		}
+		nm.RouteTargets = routeTargets(nm.Attributes)
+	}
+	return nm, nil
+}
+
+// L2VPNVpls returns an l2vpn vpls from a rib line
+func (m *RIBMessage) L2VPNVpls() (*L2VPNVplsAnnounceTextMessage, error) {
+	if m.Family() != "l2vpn vpls" {
+		return nil, fmt.Errorf("wrong entry family: %s", m.Family())
+	}
+	nm := &L2VPNVplsAnnounceTextMessage{}
+	res, err := parseVPLSLine(m.Details)
+	if err != nil {
+		return nil, err
+	}
+	nm.RouteDistinguisher = res["rd"]
+	nm.Endpoint, _ = strconv.Atoi(res["endpoint"])
+	nm.Base, _ = strconv.Atoi(res["base"])
+	nm.Offset, _ = strconv.Atoi(res["offset"])
+	nm.Size, _ = strconv.Atoi(res["size"])
+	nm.NextHop = res["next_hop"]
+	nm.Attributes = parseAttributes(res["attributes"])
+	return nm, nil
 }
 
 // Attribute represent BGP attributes for a message
@@ -256,29 +485,30 @@ type Attribute struct {
 	ClusterList       []string
 }
 
+// RouteAnnounceTextMessage represents an announce in any prefix based family in a text-based encoded exabgp message
+type RouteAnnounceTextMessage struct {
+	NLRI               string
+	PathID             string
+	Labels             []int
+	NextHop            string
+	RouteDistinguisher string
+	// RouteTargets are only set for mpls-vpn, from the extended communities
+	RouteTargets []string
+	Attributes   Attribute
+}
+
 // IPv4UnicastAnnounceTextMessage represents an ipv4-unicast announce in a text-based encoded exabgp message
 type IPv4UnicastAnnounceTextMessage struct {
 	NLRI       string
+	PathID     string
 	NextHop    string
 	Attributes Attribute
 }
 
-// IPv4MplsVPNAnnounceTextMessage represents an ipv4-mpls-vpn announce in a text-based encoded exabgp message
-type IPv4MplsVPNAnnounceTextMessage struct {
-	NLRI               string
-	Label              int
-	NextHop            string
-	RouteDistinguisher string
-	Community          string
-	Origin             string
-	ASPath             []int
-	ExtendedCommunity  string
-	LocalPreference    int
-	OriginatorID       string
-}
-
 // IPv4FlowAnnounceTextMessage represents an ipv4-flow announce in a text-based encoded exabgp message
 type IPv4FlowAnnounceTextMessage struct {
+	// Rule holds all the match components, some have no field of their own
+	Rule              string
 	DestinationIPv4   string
 	SourceIPv4        string
 	Protocol          string
@@ -290,26 +520,15 @@ type IPv4FlowAnnounceTextMessage struct {
 // IPv6UnicastAnnounceTextMessage represents an ipv6-unicast announce in a text-based encoded exabgp message
 type IPv6UnicastAnnounceTextMessage struct {
 	NLRI       string
+	PathID     string
 	NextHop    string
 	Attributes Attribute
 }
 
-// IPv6MplsVPNAnnounceTextMessage represents an ipv6-mpls-vpn announce in a text-based encoded exabgp message
-type IPv6MplsVPNAnnounceTextMessage struct {
-	NLRI               string
-	Label              int
-	NextHop            string
-	RouteDistinguisher string
-	Community          string
-	Origin             string
-	ASPath             []int
-	ExtendedCommunity  string
-	LocalPreference    int
-	OriginatorID       string
-}
-
 // IPv6FlowAnnounceTextMessage represents an ipv6-flow announce in a text-based encoded exabgp message
 type IPv6FlowAnnounceTextMessage struct {
+	// Rule holds all the match components, some have no field of their own
+	Rule              string
 	DestinationIPv6   string
 	SourceIPv6        string
 	Protocol          string
@@ -317,3 +536,14 @@ type IPv6FlowAnnounceTextMessage struct {
 	DestinationPort   string
 	ExtendedCommunity string
 }
+
+// L2VPNVplsAnnounceTextMessage represents an l2vpn-vpls announce in a text-based encoded exabgp message
+type L2VPNVplsAnnounceTextMessage struct {
+	RouteDistinguisher string
+	Endpoint           int
+	Base               int
+	Offset             int
+	Size               int
+	NextHop            string
+	Attributes         Attribute
+}
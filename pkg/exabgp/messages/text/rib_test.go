@@ -10,6 +10,7 @@ import (
 )
 
 var testRibDataFile = filepath.Join("testdata", "rib-out.txt")
+var testRibInDataFile = filepath.Join("testdata", "rib-in.txt")
 
 func testGetTotalLinesInFile(t *testing.T, f string) int {
 	file, err := os.Open(f)
@@ -38,6 +39,31 @@ func TestParseRibTestData(t *testing.T) {
 
 }
 
+func TestParseRibInTestData(t *testing.T) {
+	file, err := os.ReadFile(testRibInDataFile)
+	require.NoError(t, err)
+
+	totalLines := testGetTotalLinesInFile(t, testRibInDataFile)
+
+	ribs, err := RibFromBytes(file)
+	require.NoError(t, err)
+	require.Equal(t, totalLines, len(ribs))
+	require.Equal(t, "64497", ribs[1].PeerAS)
+	ipv4, err := ribs[1].IPv4Unicast()
+	require.NoError(t, err)
+	require.Equal(t, "10.10.1.0/24", ipv4.NLRI)
+	require.Equal(t, []int{64497, 65000}, ipv4.Attributes.ASPath)
+	require.Equal(t, []string{"64497:1", "64497:2"}, ipv4.Attributes.Community)
+}
+
+func TestParseRibStringMultiSession(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed ipv4-unicast/ipv6-unicast ipv4 unicast 192.168.88.248/29 next-hop self med 100`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	require.Equal(t, "ipv4 unicast", m.Family())
+	require.Equal(t, "192.168.88.248/29 next-hop self med 100", m.Details)
+}
+
 func TestParseRibString(t *testing.T) {
 	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 unicast 192.168.88.248/29 next-hop self med 100`
 	m, err := RibEntryFromString(testString)
@@ -159,3 +185,169 @@ func TestParseIPv6UnicastNoAttributes(t *testing.T) {
 	require.Equal(t, "self", ipv6.NextHop)
 	require.Empty(t, ipv6.Attributes)
 }
+
+func TestParseIPv4Flow(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 flow flow destination-ipv4 0.0.0.0/32 source-ipv4 0.0.0.0/32 protocol =tcp destination-port =3128 extended-community rate-limit:0`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.Equal(t, "ipv4 flow", m.Family())
+	flow, err := m.IPv4Flow()
+	require.NoError(t, err)
+	require.NotNil(t, flow)
+	require.Equal(t, "0.0.0.0/32", flow.DestinationIPv4)
+	require.Equal(t, "0.0.0.0/32", flow.SourceIPv4)
+	require.Equal(t, "=tcp", flow.Protocol)
+	require.Equal(t, "=3128", flow.DestinationPort)
+	require.Empty(t, flow.SourcePort)
+	require.Equal(t, "rate-limit:0", flow.ExtendedCommunity)
+}
+
+func TestParseIPv4FlowLists(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 flow flow destination-ipv4 10.0.0.1/32 protocol [ =tcp =udp ] destination-port [ =80 =443 ] source-port >1024 extended-community [ rate-limit:0 target:65000:1 ]`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	flow, err := m.IPv4Flow()
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1/32", flow.DestinationIPv4)
+	require.Empty(t, flow.SourceIPv4)
+	require.Equal(t, "=tcp =udp", flow.Protocol)
+	require.Equal(t, "=80 =443", flow.DestinationPort)
+	require.Equal(t, ">1024", flow.SourcePort)
+	require.Equal(t, "rate-limit:0 target:65000:1", flow.ExtendedCommunity)
+	require.Equal(t, "flow destination-ipv4 10.0.0.1/32 protocol [ =tcp =udp ] destination-port [ =80 =443 ] source-port >1024", flow.Rule)
+}
+
+func TestParseIPv6Flow(t *testing.T) {
+	var testString = `neighbor 2001::2 local-ip 2001::1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv6 flow flow destination-ipv6 2001:db8::1/128/0 next-header =udp destination-port =53 extended-community rate-limit:0`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	flow, err := m.IPv6Flow()
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::1/128/0", flow.DestinationIPv6)
+	require.Equal(t, "=udp", flow.Protocol)
+	require.Equal(t, "=53", flow.DestinationPort)
+	require.Equal(t, "rate-limit:0", flow.ExtendedCommunity)
+	require.Equal(t, "flow destination-ipv6 2001:db8::1/128/0 next-header =udp destination-port =53", flow.Rule)
+	_, err = m.IPv4Flow()
+	require.Error(t, err)
+}
+
+func TestParseL2VPNVpls(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open l2vpn vpls vpls endpoint 5 base 10702 offset 1 size 8 rd 192.168.201.1:123 next-hop 192.168.201.1 origin igp local-preference 100 extended-community [ target:54591:6 l2info:19:0:1500:111 ]`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	require.Equal(t, "l2vpn vpls", m.Family())
+	vpls, err := m.L2VPNVpls()
+	require.NoError(t, err)
+	require.Equal(t, "192.168.201.1:123", vpls.RouteDistinguisher)
+	require.Equal(t, 5, vpls.Endpoint)
+	require.Equal(t, 10702, vpls.Base)
+	require.Equal(t, 1, vpls.Offset)
+	require.Equal(t, 8, vpls.Size)
+	require.Equal(t, "192.168.201.1", vpls.NextHop)
+	require.Equal(t, 100, vpls.Attributes.LocalPreference)
+	require.Equal(t, []string{"target:54591:6", "l2info:19:0:1500:111"}, vpls.Attributes.ExtendedCommunity)
+}
+
+func TestParseIPv4MplsVPN(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 mpls-vpn 128.0.64.0/18 label 110 next-hop 10.0.99.12 rd 63333:100 origin igp as-path [ 100 500 ] local-preference 100 originator-id 10.0.99.12 extended-community target:0:0`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	require.Equal(t, "ipv4 mpls-vpn", m.Family())
+	vpn, err := m.Route()
+	require.NoError(t, err)
+	require.Equal(t, "128.0.64.0/18", vpn.NLRI)
+	require.Equal(t, []int{110}, vpn.Labels)
+	require.Equal(t, "10.0.99.12", vpn.NextHop)
+	require.Equal(t, "63333:100", vpn.RouteDistinguisher)
+	require.Equal(t, []string{"target:0:0"}, vpn.Attributes.ExtendedCommunity)
+	require.Equal(t, []string{"0:0"}, vpn.RouteTargets)
+	require.Equal(t, []int{100, 500}, vpn.Attributes.ASPath)
+	require.Equal(t, 100, vpn.Attributes.LocalPreference)
+	require.Equal(t, "10.0.99.12", vpn.Attributes.OriginatorID)
+}
+
+func TestParseIPv6MplsVPNLabelList(t *testing.T) {
+	var testString = `neighbor 2001::2 local-ip 2001::1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv6 mpls-vpn 2001:db8:1000::/64 label [ 1000 2000 ] next-hop 2001::1 rd 65000:1 community 100:1 extended-community target:65001:1`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	vpn, err := m.Route()
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8:1000::/64", vpn.NLRI)
+	require.Equal(t, []int{1000, 2000}, vpn.Labels)
+	require.Equal(t, "65000:1", vpn.RouteDistinguisher)
+	require.Equal(t, []string{"target:65001:1"}, vpn.Attributes.ExtendedCommunity)
+	require.Equal(t, []string{"65001:1"}, vpn.RouteTargets)
+	require.Equal(t, []string{"100:1"}, vpn.Attributes.Community)
+}
+
+func TestParseMplsVPNMissingRD(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 mpls-vpn 128.0.64.0/18 label 110 next-hop 10.0.99.12 origin igp`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	_, err = m.Route()
+	require.Error(t, err)
+}
+
+func TestParseRoute(t *testing.T) {
+	tc := map[string]struct {
+		line   string
+		nlri   string
+		labels []int
+		rd     string
+	}{
+		"ipv4 unicast":   {`neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 unicast 192.168.88.248/29 next-hop self med 100`, "192.168.88.248/29", nil, ""},
+		"ipv4 multicast": {`neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 multicast 239.1.0.0/16 next-hop 192.168.1.2 med 100`, "239.1.0.0/16", nil, ""},
+		"ipv4 nlri-mpls": {`neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 nlri-mpls 10.0.0.0/24 label 100 next-hop 192.168.1.2 med 100`, "10.0.0.0/24", []int{100}, ""},
+		"ipv4 mpls-vpn":  {`neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 mpls-vpn 1.4.0.0/16 label [ 1000 2000 ] next-hop 101.1.101.1 rd 65000:1 med 100`, "1.4.0.0/16", []int{1000, 2000}, "65000:1"},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			m, err := RibEntryFromString(test.line)
+			require.NoError(t, err)
+			require.Equal(t, name, m.Family())
+			route, err := m.Route()
+			require.NoError(t, err)
+			require.Equal(t, test.nlri, route.NLRI)
+			require.Equal(t, test.labels, route.Labels)
+			require.Equal(t, test.rd, route.RouteDistinguisher)
+			require.Equal(t, 100, int(route.Attributes.Med))
+		})
+	}
+}
+
+func TestParseRouteWrongFamily(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 flow flow destination-ipv4 0.0.0.0/32 source-ipv4 0.0.0.0/32 protocol =tcp destination-port =3128 extended-community rate-limit:0`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	_, err = m.Route()
+	require.Error(t, err)
+}
+
+func TestParseIPv4UnicastAddPath(t *testing.T) {
+	var testString = `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 unicast 192.168.88.248/29 path-information 0.0.0.2 next-hop self med 100`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	ipv4, err := m.IPv4Unicast()
+	require.NoError(t, err)
+	require.Equal(t, "192.168.88.248/29", ipv4.NLRI)
+	require.Equal(t, "0.0.0.2", ipv4.PathID)
+	require.Equal(t, "self", ipv4.NextHop)
+	require.Equal(t, 100, int(ipv4.Attributes.Med))
+
+	route, err := m.Route()
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.2", route.PathID)
+}
+
+func TestParseIPv6UnicastAddPath(t *testing.T) {
+	var testString = `neighbor 2001::2 local-ip 2001::1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv6 unicast 2001:db8:1000::/64 path-information 0.0.0.1 next-hop 2001:db8:ffff::1`
+	m, err := RibEntryFromString(testString)
+	require.NoError(t, err)
+	ipv6, err := m.IPv6Unicast()
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8:1000::/64", ipv6.NLRI)
+	require.Equal(t, "0.0.0.1", ipv6.PathID)
+	require.Equal(t, "2001:db8:ffff::1", ipv6.NextHop)
+}
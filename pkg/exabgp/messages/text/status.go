@@ -7,6 +7,8 @@ import (
 	"io"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 /*
@@ -15,6 +17,9 @@ Peer            AS        up/down state       |     #sent     #recvd
 192.168.1.1     64496     0:00:01 established          45          0
 */
 var summaryHeaderLine = `Peer            AS        up/down state       |     #sent     #recvd`
+
+// the up/down column is a python timedelta: [<days> day[s], ]<hours>:<minutes>:<seconds>[.<micro>]
+var rxSummaryUptime = `^(?:(?P<days>\d+) days?, )?(?P<hours>\d+):(?P<minutes>\d{2}):(?P<seconds>\d{2})(?:\.\d+)?$`
 var rxSummary = `(?P<peer_ip>\S+)\s+(?P<peer_as>\d+)\s+(?P<status>.*)\s+(?P<state>idle|active|connect|opensent|openconfirm|established)\s+(?P<sent>\d+)\s+(?P<recvd>\d+)$`
 
 func parseSummaryLine(s string) (map[string]string, error) {
@@ -35,6 +40,26 @@ func parseSummaryLine(s string) (map[string]string, error) {
 	return md, nil
 }
 
+func parseUptime(s string) (time.Duration, error) {
+	re := regexp.MustCompile(rxSummaryUptime)
+	matches := re.FindStringSubmatch(strings.TrimSpace(s))
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("unable to parse uptime: %s", s)
+	}
+	var uptime time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if matches[i+1] == "" {
+			continue
+		}
+		x, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, err
+		}
+		uptime += time.Duration(x) * unit
+	}
+	return uptime, nil
+}
+
 func SummaryEntryFromString(s string) (*NeighborSummary, error) {
 	ns := &NeighborSummary{}
 	md, err := parseSummaryLine(s)
@@ -53,6 +78,10 @@ func SummaryEntryFromString(s string) (*NeighborSummary, error) {
 		ns.Status = "down"
 	}
 	ns.State = md["state"]
+	if ns.Status == "up" {
+		// the rest of the line is still good
+		ns.Uptime, ns.UptimeError = parseUptime(md["status"])
+	}
 
 	ns.Sent, _ = strconv.Atoi(md["sent"])
 	ns.Received, _ = strconv.Atoi(md["recvd"])
@@ -86,4 +115,9 @@ type NeighborSummary struct {
 	State     string
 	Sent      int
 	Received  int
+	// Uptime is how long the session has been established for
+	Uptime time.Duration
+	// UptimeError is set when the uptime could not be parsed, it is then
+	// unknown
+	UptimeError error
 }
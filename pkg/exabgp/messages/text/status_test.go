@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -32,3 +33,44 @@ func TestParseSummaryDown(t *testing.T) {
 	require.Equal(t, "up", parsedEvents[1].Status)
 	require.Equal(t, "down", parsedEvents[2].Status)
 }
+
+func TestParseSummaryUptime(t *testing.T) {
+	file, err := os.ReadFile(testSummaryDataFile)
+	require.NoError(t, err)
+
+	parsedEvents, err := SummariesFromBytes(file)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), parsedEvents[0].Uptime)
+	require.Equal(t, time.Second, parsedEvents[1].Uptime)
+	require.Equal(t, time.Duration(0), parsedEvents[2].Uptime)
+	require.Equal(t, 22*24*time.Hour+23*time.Hour+19*time.Minute+53*time.Second, parsedEvents[3].Uptime)
+	require.Equal(t, 3*24*time.Hour+21*time.Hour+41*time.Minute+45*time.Second, parsedEvents[4].Uptime)
+}
+
+func TestParseUptime(t *testing.T) {
+	tc := map[string]time.Duration{
+		"0:32:58":           32*time.Minute + 58*time.Second,
+		"1 day, 0:00:01":    24*time.Hour + time.Second,
+		"10:00:00.123456":   10 * time.Hour,
+		"22 days, 23:19:53": 22*24*time.Hour + 23*time.Hour + 19*time.Minute + 53*time.Second,
+	}
+	for s, expected := range tc {
+		t.Run(s, func(t *testing.T) {
+			uptime, err := parseUptime(s)
+			require.NoError(t, err)
+			require.Equal(t, expected, uptime)
+		})
+	}
+	_, err := parseUptime("down")
+	require.Error(t, err)
+}
+
+func TestParseSummaryInvalidUptime(t *testing.T) {
+	// only the uptime is unknown
+	ns, err := SummaryEntryFromString(`192.168.1.1     64496     soon established          45          0`)
+	require.NoError(t, err)
+	require.Error(t, ns.UptimeError)
+	require.Equal(t, time.Duration(0), ns.Uptime)
+	require.Equal(t, "up", ns.Status)
+	require.Equal(t, 45, ns.Sent)
+}
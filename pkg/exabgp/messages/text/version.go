@@ -0,0 +1,21 @@
+package text
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// exabgpcli answers with "exabgp 4.2.11" or, depending on the version,
+// "exabgpcli version 4.2.11"
+var rxVersion = `(?im)^exabgp(?:cli)?\s+(?:version\s+)?(\S+)\s*$`
+
+// VersionFromBytes returns the exabgp version from the output of
+// exabgpcli version
+func VersionFromBytes(b []byte) (string, error) {
+	re := regexp.MustCompile(rxVersion)
+	matches := re.FindSubmatch(b)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("unable to parse version: %s", b)
+	}
+	return string(matches[1]), nil
+}
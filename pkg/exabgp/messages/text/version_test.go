@@ -0,0 +1,29 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionFromBytes(t *testing.T) {
+	tc := map[string]struct {
+		output  string
+		version string
+	}{
+		"api":      {"exabgp 4.2.11\n", "4.2.11"},
+		"cli":      {"exabgpcli version 4.2.4\n", "4.2.4"},
+		"no eol":   {"ExaBGP 4.0.1", "4.0.1"},
+		"env junk": {"\nexabgp 4.2.11\n", "4.2.11"},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			version, err := VersionFromBytes([]byte(test.output))
+			require.NoError(t, err)
+			require.Equal(t, test.version, version)
+		})
+	}
+
+	_, err := VersionFromBytes([]byte("could not send command to ExaBGP"))
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package exabgp
+
+import "fmt"
+
+// Notification represents a bgp NOTIFICATION message sent to or received
+// from a peer, the direction is the one of the event it came in
+type Notification struct {
+	Code    int
+	SubCode int
+	Data    string
+}
+
+// notification error codes and subcodes (rfc4271, rfc4486, rfc7313, rfc8203)
+var notificationCodes = map[int]string{
+	1: "Message header error",
+	2: "OPEN message error",
+	3: "UPDATE message error",
+	4: "Hold timer expired",
+	5: "State machine error",
+	6: "Cease",
+	7: "Route refresh message error",
+}
+
+var notificationSubCodes = map[int]map[int]string{
+	1: {
+		1: "Connection not synchronized",
+		2: "Bad message length",
+		3: "Bad message type",
+	},
+	2: {
+		1: "Unsupported version number",
+		2: "Bad peer AS",
+		3: "Bad BGP identifier",
+		4: "Unsupported optional parameter",
+		5: "Authentication notification (deprecated)",
+		6: "Unacceptable hold time",
+		7: "Unsupported capability",
+	},
+	3: {
+		1:  "Malformed attribute list",
+		2:  "Unrecognized well-known attribute",
+		3:  "Missing well-known attribute",
+		4:  "Attribute flags error",
+		5:  "Attribute length error",
+		6:  "Invalid ORIGIN attribute",
+		7:  "AS routing loop",
+		8:  "Invalid NEXT_HOP attribute",
+		9:  "Optional attribute error",
+		10: "Invalid network field",
+		11: "Malformed AS_PATH",
+	},
+	5: {
+		1: "Receive unexpected message in OpenSent state",
+		2: "Receive unexpected message in OpenConfirm state",
+		3: "Receive unexpected message in Established state",
+	},
+	6: {
+		1: "Maximum number of prefixes reached",
+		2: "Administrative shutdown",
+		3: "Peer de-configured",
+		4: "Administrative reset",
+		5: "Connection rejected",
+		6: "Other configuration change",
+		7: "Connection collision resolution",
+		8: "Out of resources",
+		9: "Hard reset",
+	},
+	7: {
+		1: "Invalid message length",
+	},
+}
+
+// CodeName returns the human readable name of the notification error code
+func (n *Notification) CodeName() string {
+	if name, ok := notificationCodes[n.Code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown code %d", n.Code)
+}
+
+// SubCodeName returns the human readable name of the notification error subcode
+func (n *Notification) SubCodeName() string {
+	if name, ok := notificationSubCodes[n.Code][n.SubCode]; ok {
+		return name
+	}
+	if n.SubCode == 0 {
+		return "Unspecific"
+	}
+	return fmt.Sprintf("Unknown subcode %d", n.SubCode)
+}
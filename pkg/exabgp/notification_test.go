@@ -0,0 +1,55 @@
+package exabgp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationReceive(t *testing.T) {
+	var testString = `{ "exabgp": "4.0.1", "time": 1554957921.8339317, "host" : "node1", "pid" : 11335, "ppid" : 1, "counter": 2, "type": "notification", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "notification": { "code": 2, "subcode": 7, "data": "0x010400380001" }  } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Equal(t, "receive", evt.Direction)
+	require.NotNil(t, evt.Notification)
+	require.Equal(t, 2, evt.Notification.Code)
+	require.Equal(t, 7, evt.Notification.SubCode)
+	require.Equal(t, "0x010400380001", evt.Notification.Data)
+	require.Equal(t, "OPEN message error", evt.Notification.CodeName())
+	require.Equal(t, "Unsupported capability", evt.Notification.SubCodeName())
+}
+
+func TestNotificationSend(t *testing.T) {
+	var testString = `{ "exabgp": "4.2.4", "time": 1554957921.8339317, "host" : "node1", "pid" : 11335, "ppid" : 1, "counter": 7, "type": "notification", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "notification": { "code": 6, "subcode": 2, "data": "" }  } }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Equal(t, "send", evt.Direction)
+	require.Equal(t, "Cease", evt.Notification.CodeName())
+	require.Equal(t, "Administrative shutdown", evt.Notification.SubCodeName())
+}
+
+func TestNotificationShutdown(t *testing.T) {
+	var testString = `{ "exabgp": "4.2.4", "time": 1554957921.8339317, "host" : "node1", "pid" : 11335, "ppid" : 1, "counter": 8, "type": "notification", "notification": "shutdown" }`
+	evt, err := ParseEvent([]byte(testString))
+	require.NoError(t, err)
+	require.Nil(t, evt.Notification)
+}
+
+func TestNotificationNames(t *testing.T) {
+	tc := map[string]struct {
+		n       Notification
+		code    string
+		subcode string
+	}{
+		"hold timer":      {Notification{Code: 4}, "Hold timer expired", "Unspecific"},
+		"unknown code":    {Notification{Code: 42, SubCode: 1}, "Unknown code 42", "Unknown subcode 1"},
+		"unknown subcode": {Notification{Code: 6, SubCode: 99}, "Cease", "Unknown subcode 99"},
+		"bad peer as":     {Notification{Code: 2, SubCode: 2}, "OPEN message error", "Bad peer AS"},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.code, test.n.CodeName())
+			require.Equal(t, test.subcode, test.n.SubCodeName())
+		})
+	}
+}
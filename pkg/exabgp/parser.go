@@ -0,0 +1,193 @@
+package exabgp
+
+import (
+	"sync"
+	"time"
+)
+
+// statuses of an exabgp instance
+const (
+	StatusUnknown = "unknown"
+	StatusUp      = "up"
+	StatusDown    = "down"
+)
+
+// Instance identifies the exabgp process events come from
+type Instance struct {
+	Version string
+	Host    string
+	PID     int
+}
+
+// Parser parses the events of a single exabgp instance and keeps track of
+// the status of the instance and of each of its peers.
+// It is safe for concurrent use.
+type Parser struct {
+	sessions *Sessions
+	status   string
+	reason   string
+	lastSeen time.Time
+	instance Instance
+	// signals counts the signals received by exabgp by name, lastSignal
+	// holds when each was last reported
+	signals    map[string]int
+	lastSignal map[string]time.Time
+	lastReload time.Time
+	// counters holds the last event counter seen for each exabgp process,
+	// the events of all its neighbors share the sequence
+	counters map[int]int64
+	events   int
+	gaps     int
+	sync.RWMutex
+}
+
+// NewParser returns a parser for a new exabgp instance
+func NewParser() *Parser {
+	return &Parser{
+		sessions:   NewSessions(),
+		status:     StatusUnknown,
+		reason:     "no known last status",
+		lastSeen:   time.Now(),
+		signals:    make(map[string]int),
+		lastSignal: make(map[string]time.Time),
+		counters:   make(map[int]int64),
+	}
+}
+
+// Parse parses an exabgp json message and applies it to the tracked status.
+// The returned event is marked as Restarted when its pid differs from the
+// one of the previous event, the routes of the old process are then gone.
+func (p *Parser) Parse(data []byte) (*Event, error) {
+	evt, err := ParseEvent(data)
+	if err != nil {
+		return evt, err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.lastSeen = time.Now()
+	if evt.PID != 0 {
+		evt.Restarted = p.instance.PID != 0 && evt.PID != p.instance.PID
+		p.instance = Instance{Version: evt.Version, Host: evt.Host, PID: evt.PID}
+	}
+	if evt.Restarted {
+		p.counters = make(map[int]int64)
+	}
+	p.events++
+	if evt.Counter != 0 {
+		// we may have been started after exabgp so only the following
+		// events are checked
+		if last, ok := p.counters[evt.PID]; ok && evt.Counter != last+1 {
+			p.gaps++
+		}
+		p.counters[evt.PID] = evt.Counter
+	}
+	if s := evt.Signal; s != nil {
+		// exabgp reports a signal to each neighbor with signal in its api,
+		// all at once
+		if last, ok := p.lastSignal[s.Name]; !ok || evt.Time.Sub(last) > time.Second {
+			p.signals[s.Name]++
+			if s.IsReload() {
+				p.lastReload = evt.Time.Time
+			}
+		}
+		p.lastSignal[s.Name] = evt.Time.Time
+	}
+	p.sessions.Update(evt)
+	if evt.Shutdown {
+		p.status = StatusDown
+		p.reason = "exabgp is shutting down"
+	} else {
+		p.status = StatusUp
+		p.reason = ""
+	}
+	return evt, nil
+}
+
+// Status returns the last known status of the exabgp instance
+func (p *Parser) Status() string {
+	p.RLock()
+	defer p.RUnlock()
+	return p.status
+}
+
+// SetStatus overrides the status of the exabgp instance, for when we learn
+// about it other than through its events (the event stream closing)
+func (p *Parser) SetStatus(status string, reason string) {
+	p.Lock()
+	defer p.Unlock()
+	p.status = status
+	p.reason = reason
+}
+
+// LastSeen returns when the last event was parsed, or when the parser was
+// created if there was none yet
+func (p *Parser) LastSeen() time.Time {
+	p.RLock()
+	defer p.RUnlock()
+	return p.lastSeen
+}
+
+// Instance returns the exabgp process the last event came from, it is false
+// until an event was parsed
+func (p *Parser) Instance() (Instance, bool) {
+	p.RLock()
+	defer p.RUnlock()
+	return p.instance, p.instance.PID != 0
+}
+
+// EventsReceived returns how many events were parsed
+func (p *Parser) EventsReceived() int {
+	p.RLock()
+	defer p.RUnlock()
+	return p.events
+}
+
+// SequenceGaps returns how many times the counter of an event didn't follow
+// the one of the previous event of the same peer, because events were lost
+// (or could not be parsed) or came out of order
+func (p *Parser) SequenceGaps() int {
+	p.RLock()
+	defer p.RUnlock()
+	return p.gaps
+}
+
+// Signals returns how many times exabgp received each signal
+func (p *Parser) Signals() map[string]int {
+	p.RLock()
+	defer p.RUnlock()
+	signals := make(map[string]int, len(p.signals))
+	for name, count := range p.signals {
+		signals[name] = count
+	}
+	return signals
+}
+
+// LastReload returns the time of the last signal which made exabgp reload,
+// it is zero if there was none
+func (p *Parser) LastReload() time.Time {
+	p.RLock()
+	defer p.RUnlock()
+	return p.lastReload
+}
+
+// StatusReason returns any reason we may have for the current status
+func (p *Parser) StatusReason() string {
+	p.RLock()
+	defer p.RUnlock()
+	return p.reason
+}
+
+// PeerStatus returns the last known state of a peer and the reason given for it
+func (p *Parser) PeerStatus(peer string) (string, string) {
+	session, ok := p.sessions.Session(peer)
+	if !ok || session.State == "" {
+		return StatusUnknown, "no known last status"
+	}
+	return session.State, session.Reason
+}
+
+// Sessions returns the sessions of the exabgp instance
+func (p *Parser) Sessions() *Sessions {
+	return p.sessions
+}
@@ -0,0 +1,145 @@
+package exabgp
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserStatus(t *testing.T) {
+	p := NewParser()
+	require.Equal(t, StatusUnknown, p.Status())
+	require.NotEmpty(t, p.StatusReason())
+	state, _ := p.PeerStatus("192.168.1.2")
+	require.Equal(t, StatusUnknown, state)
+
+	_, err := p.Parse([]byte(`{ "exabgp": "4.0.1", "time": 1554851049.9405053, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 26, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "down", "reason": "peer reset, message (closing connection) error(the TCP connection was closed by the remote end)" } }`))
+	require.NoError(t, err)
+	// a peer going down doesn't mean exabgp is
+	require.Equal(t, StatusUp, p.Status())
+	state, reason := p.PeerStatus("192.168.1.2")
+	require.Equal(t, "down", state)
+	require.Contains(t, reason, "TCP connection was closed")
+
+	_, err = p.Parse([]byte(`{ "exabgp": "4.2.4", "time": 1554957921.8339317, "host" : "node1", "pid" : 11335, "ppid" : 1, "counter": 8, "type": "notification", "notification": "shutdown" }`))
+	require.NoError(t, err)
+	require.Equal(t, StatusDown, p.Status())
+	require.NotEmpty(t, p.StatusReason())
+}
+
+func TestParserSetStatus(t *testing.T) {
+	p := NewParser()
+	created := p.LastSeen()
+	require.False(t, created.IsZero())
+
+	_, err := p.Parse([]byte(`{ "exabgp": "4.0.1", "time": 1554851049.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`))
+	require.NoError(t, err)
+	require.False(t, p.LastSeen().Before(created))
+
+	p.SetStatus(StatusDown, "event stream closed")
+	require.Equal(t, StatusDown, p.Status())
+	require.Equal(t, "event stream closed", p.StatusReason())
+	// the peers keep their last known state
+	state, _ := p.PeerStatus("192.168.1.2")
+	require.Equal(t, "up", state)
+}
+
+func TestParserSignals(t *testing.T) {
+	p := NewParser()
+	for _, line := range []string{
+		// a reload is reported once per neighbor
+		`{ "exabgp": "4.2.4", "time": 1555437135.581991, "host" : "node1", "pid" : 22, "ppid" : 20, "counter": 5, "type": "signal", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "code": 10, "name": "SIGUSR1" } }`,
+		`{ "exabgp": "4.2.4", "time": 1555437135.582017, "host" : "node1", "pid" : 22, "ppid" : 20, "counter": 6, "type": "signal", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.3" }, "asn": { "local": 64496, "peer": 64496 } , "code": 10, "name": "SIGUSR1" } }`,
+		`{ "exabgp": "4.2.4", "time": 1555437200.123456, "host" : "node1", "pid" : 22, "ppid" : 20, "counter": 7, "type": "signal", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "code": 10, "name": "SIGUSR1" } }`,
+		`{ "exabgp": "4.2.4", "time": 1555437300.123456, "host" : "node1", "pid" : 22, "ppid" : 20, "counter": 8, "type": "signal", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "code": 14, "name": "SIGALRM" } }`,
+	} {
+		_, err := p.Parse([]byte(line))
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]int{"SIGUSR1": 2, "SIGALRM": 1}, p.Signals())
+	require.Equal(t, int64(1555437200), p.LastReload().Unix())
+}
+
+func TestParserRestart(t *testing.T) {
+	p := NewParser()
+	_, ok := p.Instance()
+	require.False(t, ok)
+	for _, test := range []struct {
+		line      string
+		restarted bool
+	}{
+		{`{ "exabgp": "4.0.1", "time": 1554851049.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`, false},
+		{`{ "exabgp": "4.0.1", "time": 1554851050.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 26, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`, false},
+		{`{ "exabgp": "4.0.1", "time": 1554851090.928668, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 1, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "connected" } }`, true},
+		{`{ "exabgp": "4.0.1", "time": 1554851091.928668, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 2, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`, false},
+	} {
+		evt, err := p.Parse([]byte(test.line))
+		require.NoError(t, err)
+		require.Equal(t, test.restarted, evt.Restarted)
+	}
+	instance, ok := p.Instance()
+	require.True(t, ok)
+	require.Equal(t, Instance{Version: "4.0.1", Host: "node1", PID: 8618}, instance)
+}
+
+func TestParserSequenceGaps(t *testing.T) {
+	p := NewParser()
+	for _, line := range []string{
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 7, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 8, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.3" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		// 9 was lost
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 10, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		// a new exabgp starts counting again
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8619, "ppid" : 1, "counter": 1, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+	} {
+		_, err := p.Parse([]byte(line))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, p.EventsReceived())
+	require.Equal(t, 1, p.SequenceGaps())
+}
+
+func TestParserSequenceInterleavedPeers(t *testing.T) {
+	p := NewParser()
+	// the events of all peers share the counter of the exabgp process
+	for _, line := range []string{
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 7, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 8, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.3" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 9, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.3" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+		`{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 10, "type": "keepalive", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`,
+	} {
+		_, err := p.Parse([]byte(line))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, p.EventsReceived())
+	require.Equal(t, 0, p.SequenceGaps())
+}
+
+func TestParserInstancesAreSeparate(t *testing.T) {
+	p1 := NewParser()
+	p2 := NewParser()
+	_, err := p1.Parse([]byte(`{ "exabgp": "4.0.1", "time": 1554851049.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`))
+	require.NoError(t, err)
+	require.Equal(t, StatusUp, p1.Status())
+	require.Equal(t, StatusUnknown, p2.Status())
+	state, _ := p2.PeerStatus("192.168.1.2")
+	require.Equal(t, StatusUnknown, state)
+}
+
+func TestParserConcurrent(t *testing.T) {
+	p := NewParser()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Parse([]byte(`{ "exabgp": "4.0.1", "time": 1554851049.928668, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "up" } }`))
+			require.NoError(t, err)
+			p.Status()
+			p.PeerStatus("192.168.1.2")
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, StatusUp, p.Status())
+}
@@ -0,0 +1,48 @@
+package exabgp
+
+import "strings"
+
+// down reasons reported by NormalizeDownReason
+const (
+	DownReasonHoldTimerExpired     = "hold timer expired"
+	DownReasonNotificationReceived = "notification received"
+	DownReasonNotificationSent     = "notification sent"
+	DownReasonTCPClosed            = "tcp closed"
+	DownReasonConnectionRefused    = "connection refused"
+	DownReasonConnectionTimeout    = "connection timeout"
+	DownReasonUnspecified          = "unspecified"
+	DownReasonOther                = "other"
+)
+
+// the first match wins so the more specific patterns come first, a hold timer
+// expiry for instance is reported by exabgp as a notification it sent
+var downReasons = []struct {
+	pattern string
+	reason  string
+}{
+	{"hold timer expired", DownReasonHoldTimerExpired},
+	{"notification received", DownReasonNotificationReceived},
+	{"notification sent", DownReasonNotificationSent},
+	{"tcp connection was closed", DownReasonTCPClosed},
+	{"closing connection", DownReasonTCPClosed},
+	{"connection refused", DownReasonConnectionRefused},
+	{"timed out", DownReasonConnectionTimeout},
+}
+
+// NormalizeDownReason maps the free form reason exabgp gives for a peer going
+// down onto a bounded set of reasons, so it can be used as a label.
+// peer reset, message (closing connection) error(the TCP connection was closed by the remote end)
+// peer reset, message (notification received (2,7)) error(OPEN message error / Unsupported Capability / )
+func NormalizeDownReason(reason string) string {
+	r := strings.ToLower(reason)
+	for _, d := range downReasons {
+		if strings.Contains(r, d.pattern) {
+			return d.reason
+		}
+	}
+	r = strings.TrimSpace(strings.TrimPrefix(r, "peer reset,"))
+	if r == "" || r == "message () error()" {
+		return DownReasonUnspecified
+	}
+	return DownReasonOther
+}
@@ -0,0 +1,26 @@
+package exabgp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDownReason(t *testing.T) {
+	tc := map[string]string{
+		"peer reset, message (closing connection) error(the TCP connection was closed by the remote end)":         DownReasonTCPClosed,
+		"peer reset, message (notification received (2,7)) error(OPEN message error / Unsupported Capability / )": DownReasonNotificationReceived,
+		"peer reset, message (notification sent (6,2)) error(Cease / Administrative Shutdown / )":                 DownReasonNotificationSent,
+		"peer reset, message (notification sent (4,0)) error(Hold timer expired / Unspecific / )":                 DownReasonHoldTimerExpired,
+		"peer reset, message (connection to 127.0.0.1 failed) error([Errno 111] Connection refused)":              DownReasonConnectionRefused,
+		"peer reset, message (connection to 127.0.0.1 failed) error(timed out)":                                   DownReasonConnectionTimeout,
+		"peer reset, message () error()": DownReasonUnspecified,
+		"":                               DownReasonUnspecified,
+		"peer reset, message (something new) error(unexpected)": DownReasonOther,
+	}
+	for reason, expected := range tc {
+		t.Run(reason, func(t *testing.T) {
+			require.Equal(t, expected, NormalizeDownReason(reason))
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package exabgp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages"
+)
+
+// WithdrawnRetention is how long withdrawn routes are kept in the RIB, so
+// every scrape in that time reports them as down before their series go away
+const WithdrawnRetention = 5 * time.Minute
+
+// RouteKey uniquely identifies a route in the RIB
+type RouteKey struct {
+	Peer      string
+	Direction string
+	Family    string
+	RD        string
+	NLRI      string
+	PathID    string
+}
+
+// Route represents a single nlri exchanged with a peer
+type Route struct {
+	Peer       Peer
+	Self       Self
+	Direction  string
+	Family     string
+	RD         string
+	NLRI       string
+	PathID     string
+	NextHop    string
+	Labels     []int
+	Attributes messages.Attribute
+	Flow       *Flow
+	VPLS       *VPLS
+	Withdrawn  bool
+	// withdrawnAt is when the route was withdrawn, for its expiry
+	withdrawnAt time.Time
+}
+
+// Flow represents the match components of a flowspec rule
+type Flow struct {
+	Destination     []string
+	Source          []string
+	Protocol        []string
+	DestinationPort []string
+	SourcePort      []string
+}
+
+// Key returns the key the route is stored under in the RIB
+func (r *Route) Key() RouteKey {
+	return RouteKey{
+		Peer:      r.Peer.IP,
+		Direction: r.Direction,
+		Family:    r.Family,
+		RD:        r.RD,
+		NLRI:      r.NLRI,
+		PathID:    r.PathID,
+	}
+}
+
+// RouteTargets returns the route-target extended communities attached to a
+// vpn route, they are only meaningful along with a route distinguisher
+func (r *Route) RouteTargets() []string {
+	targets := []string{}
+	if r.RD == "" {
+		return targets
+	}
+	for _, ec := range r.Attributes.ExtendedCommunity {
+		if strings.HasPrefix(ec.String, "target:") {
+			targets = append(targets, strings.TrimPrefix(ec.String, "target:"))
+		}
+	}
+	return targets
+}
+
+// VPLS represents the label block advertised in an l2vpn vpls nlri
+type VPLS struct {
+	RD       string
+	Endpoint int
+	Base     int
+	Offset   int
+	Size     int
+}
+
+// NLRI returns what identifies the label block of a site, the base and size
+// may change when it is re-announced
+func (v *VPLS) NLRI() string {
+	return fmt.Sprintf("rd %s endpoint %d offset %d", v.RD, v.Endpoint, v.Offset)
+}
+
+// RIB is an in-memory model of the routes exchanged with each peer.
+// A re-announce of an nlri replaces the existing entry. A withdraw marks the
+// entry as withdrawn so it is reported as down, it expires after
+// WithdrawnRetention and is deleted by a later update.
+type RIB struct {
+	routes map[RouteKey]*Route
+	// lastExpiry is when the expired routes were last deleted
+	lastExpiry time.Time
+	sync.RWMutex
+}
+
+// NewRIB returns an empty RIB
+func NewRIB() *RIB {
+	return &RIB{
+		routes: make(map[RouteKey]*Route),
+	}
+}
+
+// Update applies the announcements and withdrawals in an event to the RIB
+func (r *RIB) Update(evt *Event) {
+	announcements := evt.GetAnnouncements()
+	withdrawals := evt.GetWithdrawals()
+	if announcements == nil && withdrawals == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.expire(time.Now())
+	for _, nexthops := range announcements {
+		for _, a := range nexthops {
+			for _, route := range a.Routes {
+				r.announce(fromEvent(evt, route))
+			}
+		}
+	}
+	for _, w := range withdrawals {
+		for _, route := range w.Routes {
+			r.withdraw(fromEvent(evt, route))
+		}
+	}
+}
+
+// fromEvent returns a copy of a decoded route tagged with the peer and
+// direction of the event it came from
+func fromEvent(evt *Event, route *Route) *Route {
+	rt := *route
+	rt.Peer = Peer{IP: evt.Peer.IP, ASN: evt.Peer.ASN}
+	rt.Self = evt.Self
+	rt.Direction = evt.Direction
+	return &rt
+}
+
+func (r *RIB) announce(route *Route) {
+	r.routes[route.Key()] = route
+}
+
+// withdraw ignores the routes we never saw announced, there is no series
+// to report as down
+func (r *RIB) withdraw(route *Route) {
+	if existing, ok := r.routes[route.Key()]; ok {
+		existing.markWithdrawn(time.Now())
+	}
+}
+
+// markWithdrawn keeps the time of the first withdraw, repeating it doesn't
+// delay the expiry
+func (r *Route) markWithdrawn(now time.Time) {
+	if !r.Withdrawn {
+		r.Withdrawn = true
+		r.withdrawnAt = now
+	}
+}
+
+func (r *Route) expired(now time.Time) bool {
+	return r.Withdrawn && now.Sub(r.withdrawnAt) > WithdrawnRetention
+}
+
+// expire deletes the expired routes, at most once per WithdrawnRetention as
+// it goes through the whole RIB
+func (r *RIB) expire(now time.Time) {
+	if now.Sub(r.lastExpiry) < WithdrawnRetention {
+		return
+	}
+	r.lastExpiry = now
+	for k, route := range r.routes {
+		if route.expired(now) {
+			delete(r.routes, k)
+		}
+	}
+}
+
+// Routes returns a snapshot of all routes in the RIB, including the
+// withdrawn ones which have not expired yet
+func (r *RIB) Routes() []Route {
+	r.RLock()
+	defer r.RUnlock()
+	now := time.Now()
+	routes := make([]Route, 0, len(r.routes))
+	for _, route := range r.routes {
+		if !route.expired(now) {
+			routes = append(routes, *route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i].Key(), routes[j].Key()
+		if a.Peer != b.Peer {
+			return a.Peer < b.Peer
+		}
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		if a.Family != b.Family {
+			return a.Family < b.Family
+		}
+		if a.RD != b.RD {
+			return a.RD < b.RD
+		}
+		if a.NLRI != b.NLRI {
+			return a.NLRI < b.NLRI
+		}
+		return a.PathID < b.PathID
+	})
+	return routes
+}
+
+// WithdrawPeer marks all routes exchanged with a peer as withdrawn.
+// When a session goes down BGP implicitly withdraws every route learned
+// from or announced to the peer, they will be re-learned once the session
+// is re-established.
+func (r *RIB) WithdrawPeer(peer string) {
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	r.expire(now)
+	for k, route := range r.routes {
+		if k.Peer == peer {
+			route.markWithdrawn(now)
+		}
+	}
+}
+
+// Reset drops all routes, for when exabgp restarted and they will be
+// announced again
+func (r *RIB) Reset() {
+	r.Lock()
+	defer r.Unlock()
+	r.routes = make(map[RouteKey]*Route)
+}
@@ -0,0 +1,228 @@
+package exabgp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRIBUpdate(t *testing.T, rib *RIB, events ...string) {
+	for _, e := range events {
+		evt, err := ParseEvent([]byte(e))
+		require.NoError(t, err)
+		rib.Update(evt)
+	}
+}
+
+func TestRIBAnnounce(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "192.168.88.2/32", routes[0].NLRI)
+	require.Equal(t, "ipv4 unicast", routes[0].Family)
+	require.Equal(t, "192.168.1.184", routes[0].NextHop)
+	require.Equal(t, "send", routes[0].Direction)
+	require.Equal(t, int64(100), routes[0].Attributes.Med)
+	require.False(t, routes[0].Withdrawn)
+}
+
+func TestRIBReannounceReplaces(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554843224.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 200, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, int64(200), routes[0].Attributes.Med)
+}
+
+func TestRIBWithdraw(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554850881.0072424, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 unicast": [ "192.168.88.2/32" ] } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.True(t, routes[0].Withdrawn)
+	// the attributes of the announce are kept so the same series is reported as down
+	require.Equal(t, int64(100), routes[0].Attributes.Med)
+}
+
+func TestRIBWithdrawUnseen(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554987394.5413187, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 14, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "withdraw": { "ipv4 unicast": [ "192.168.87.0/24", "192.168.86.0/24", "192.168.88.0/24" ] } } } } }`,
+	)
+	require.Empty(t, rib.Routes())
+}
+
+func TestRIBWithdrawnExpire(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32", "192.168.88.3/32" ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554850881.0072424, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 unicast": [ "192.168.88.2/32" ] } } } } }`,
+	)
+	// the withdrawn route is reported as down to every scrape
+	for i := 0; i < 2; i++ {
+		routes := rib.Routes()
+		require.Len(t, routes, 2)
+		require.True(t, routes[0].Withdrawn)
+		require.False(t, routes[1].Withdrawn)
+	}
+
+	// until it expires
+	for _, route := range rib.routes {
+		if route.Withdrawn {
+			route.withdrawnAt = time.Now().Add(-WithdrawnRetention - time.Second)
+		}
+	}
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "192.168.88.3/32", routes[0].NLRI)
+	require.Len(t, rib.routes, 2)
+
+	// expired routes are deleted by a later update
+	rib.lastExpiry = time.Time{}
+	rib.WithdrawPeer("192.168.1.2")
+	require.Len(t, rib.routes, 1)
+	require.Len(t, rib.Routes(), 1)
+}
+
+func TestRIBDirectionsAreSeparate(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1593585006.358343, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "2001::1", "peer": "2001::2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv6 unicast": { "2001:db8:ffff::1": [ { "nlri": "2001:db8:1000::/64" } ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1593585007.358343, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "2001::1", "peer": "2001::2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv6 unicast": { "2001::2": [ { "nlri": "2001:db8:1000::/64" } ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "receive", routes[0].Direction)
+	require.Equal(t, "send", routes[1].Direction)
+}
+
+func TestRIBPeerDown(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.3" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+	)
+	rib.WithdrawPeer("192.168.1.2")
+	routes := rib.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "192.168.1.2", routes[0].Peer.IP)
+	require.True(t, routes[0].Withdrawn)
+	require.Equal(t, "192.168.1.3", routes[1].Peer.IP)
+	require.False(t, routes[1].Withdrawn)
+
+	// routes are re-learned after the session is re-established
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843225.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 14, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+	)
+	routes = rib.Routes()
+	require.False(t, routes[0].Withdrawn)
+}
+
+func TestRIBReset(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "med": 100, "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.184": [ "192.168.88.2/32" ] } } } } } }`,
+	)
+	require.Len(t, rib.Routes(), 1)
+	rib.Reset()
+	require.Empty(t, rib.Routes())
+}
+
+func TestRIBFlow(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554987723.0377939, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 15, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100, "extended-community": [ { "value": 9225060887780392960, "string": "rate-limit:0" } ] }, "announce": { "ipv4 flow": { "no-nexthop": [ { "destination-ipv4": [ "10.0.0.1/32" ], "source-ipv4": [ "192.0.2.0/24" ], "protocol": [ "=tcp" ], "destination-port": [ "=80", "=443" ], "string": "flow destination-ipv4 10.0.0.1/32 source-ipv4 192.0.2.0/24 protocol =tcp destination-port [ =80 =443 ]" } ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "ipv4 flow", routes[0].Family)
+	require.NotNil(t, routes[0].Flow)
+	require.Equal(t, []string{"10.0.0.1/32"}, routes[0].Flow.Destination)
+	require.Equal(t, []string{"192.0.2.0/24"}, routes[0].Flow.Source)
+	require.Equal(t, []string{"=tcp"}, routes[0].Flow.Protocol)
+	require.Equal(t, []string{"=80", "=443"}, routes[0].Flow.DestinationPort)
+	require.Equal(t, "rate-limit:0", routes[0].Attributes.ExtendedCommunity[0].String)
+	require.False(t, routes[0].Withdrawn)
+
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554988905.0260825, "host" : "node1", "pid" : 14339, "ppid" : 1, "counter": 16, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 flow": [ { "destination-ipv4": [ "10.0.0.1/32" ], "source-ipv4": [ "192.0.2.0/24" ], "protocol": [ "=tcp" ], "destination-port": [ "=80", "=443" ], "string": "flow destination-ipv4 10.0.0.1/32 source-ipv4 192.0.2.0/24 protocol =tcp destination-port [ =80 =443 ]" } ] } } } } }`,
+	)
+	routes = rib.Routes()
+	require.Len(t, routes, 1)
+	require.True(t, routes[0].Withdrawn)
+	require.Equal(t, "rate-limit:0", routes[0].Attributes.ExtendedCommunity[0].String)
+}
+
+func TestRIBL2VPNVpls(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "l2vpn vpls": { "192.168.201.1": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "l2vpn vpls", routes[0].Family)
+	require.NotNil(t, routes[0].VPLS)
+	require.Equal(t, 10702, routes[0].VPLS.Base)
+	require.False(t, routes[0].Withdrawn)
+
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "withdraw": { "l2vpn vpls": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } }`,
+	)
+	routes = rib.Routes()
+	require.Len(t, routes, 1)
+	require.True(t, routes[0].Withdrawn)
+}
+
+func TestRIBL2VPNVplsReannounceReplaces(t *testing.T) {
+	rib := NewRIB()
+	// the same site re-announced with a new label block
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.0.1", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "l2vpn vpls": { "192.168.201.1": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554993226.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.158" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "l2vpn vpls": { "192.168.201.1": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10800, "offset": 1, "size": 16 } ] } } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 1)
+	require.Equal(t, 10800, routes[0].VPLS.Base)
+	require.Equal(t, 16, routes[0].VPLS.Size)
+}
+
+func TestRIBMplsVPNKeyedByRD(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.2.4", "time": 1554993216.0336444, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 mpls-vpn": { "10.0.99.12": [ { "label": [ [ 110, 1761 ] ], "rd": "63333:100", "nlri": "128.0.64.0/18" }, { "label": [ [ 120, 1921 ] ], "rd": "63333:200", "nlri": "128.0.64.0/18" } ] } } } } } }`,
+		`{ "exabgp": "4.2.4", "time": 1554993286.2157118, "host" : "node1", "pid" : 17142, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "withdraw": { "ipv4 mpls-vpn": [ { "label": [ [ 120, 1921 ] ], "rd": "63333:200", "nlri": "128.0.64.0/18" } ] } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "63333:100", routes[0].RD)
+	require.Equal(t, []int{110}, routes[0].Labels)
+	require.False(t, routes[0].Withdrawn)
+	require.Equal(t, "63333:200", routes[1].RD)
+	require.True(t, routes[1].Withdrawn)
+}
+
+func TestRIBAddPath(t *testing.T) {
+	rib := NewRIB()
+	testRIBUpdate(t, rib,
+		`{ "exabgp": "4.2.4", "time": 1554843223.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 11, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "ipv4 unicast": { "192.168.1.10": [ { "path-information": "0.0.0.1", "nlri": "10.0.0.0/24" } ], "192.168.1.11": [ { "path-information": "0.0.0.2", "nlri": "10.0.0.0/24" } ] } } } } } }`,
+		`{ "exabgp": "4.2.4", "time": 1554843224.5592246, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "message": { "update": { "withdraw": { "ipv4 unicast": [ { "path-information": "0.0.0.1", "nlri": "10.0.0.0/24" } ] } } } } }`,
+	)
+	routes := rib.Routes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "0.0.0.1", routes[0].PathID)
+	require.Equal(t, "192.168.1.10", routes[0].NextHop)
+	require.True(t, routes[0].Withdrawn)
+	require.Equal(t, "0.0.0.2", routes[1].PathID)
+	require.Equal(t, "192.168.1.11", routes[1].NextHop)
+	require.False(t, routes[1].Withdrawn)
+}
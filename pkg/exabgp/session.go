@@ -0,0 +1,211 @@
+package exabgp
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages"
+)
+
+// Open represents a bgp OPEN message sent to or received from a peer
+type Open struct {
+	Version  int
+	ASN      int
+	HoldTime int
+	RouterID string
+	// Capabilities holds the sorted names of the advertised capabilities
+	// ("asn4", "graceful-restart", "route-refresh", ...)
+	Capabilities []string
+	// Families holds the sorted "<afi> <safi>" families advertised in the
+	// multiprotocol capability
+	Families []string
+}
+
+func newOpen(m *messages.OpenMessage) *Open {
+	o := &Open{
+		Version:      m.Version,
+		ASN:          m.ASN,
+		HoldTime:     m.HoldTime,
+		RouterID:     m.RouterID,
+		Capabilities: []string{},
+		Families:     []string{},
+	}
+	seen := make(map[string]bool)
+	for key, c := range m.Capabilities {
+		name := c.Name
+		if name == "" {
+			name = key
+		}
+		// exabgp names some capabilities with spaces ("graceful restart")
+		name = strings.ReplaceAll(name, " ", "-")
+		// the same capability can be advertised under several codes
+		// (route-refresh is also sent with the pre-standard code 128)
+		if !seen[name] {
+			seen[name] = true
+			o.Capabilities = append(o.Capabilities, name)
+		}
+		for _, f := range c.Families {
+			o.Families = append(o.Families, strings.Replace(f, "/", " ", 1))
+		}
+	}
+	sort.Strings(o.Capabilities)
+	sort.Strings(o.Families)
+	return o
+}
+
+// Session holds what we know about the bgp session with a peer
+type Session struct {
+	Peer Peer
+	Self Self
+	// Sent is the last open message exabgp sent to the peer
+	Sent *Open
+	// Received is the last open message exabgp received from the peer
+	Received *Open
+	// State is the last state reported for the peer (connected, up, down)
+	State string
+	// Reason is the reason given with the last state, if any
+	Reason string
+	// LastStateChange is the time of the event that changed the state
+	LastStateChange time.Time
+	// EstablishedTransitions counts how many times the session came up
+	EstablishedTransitions int
+	// DownReasons counts the down events by normalized reason
+	DownReasons map[string]int
+	// Established is the time the session last came up
+	Established time.Time
+	// EORSent and EORReceived hold when the End-of-RIB of each family was
+	// sent to and received from the peer since the last change of state
+	EORSent     map[string]time.Time
+	EORReceived map[string]time.Time
+}
+
+// HoldTime returns the hold time negotiated with the peer, which is the
+// lowest of the two advertised ones. It is only known once opens have been
+// seen in both directions.
+func (s *Session) HoldTime() (int, bool) {
+	if s.Sent == nil || s.Received == nil {
+		return 0, false
+	}
+	if s.Sent.HoldTime < s.Received.HoldTime {
+		return s.Sent.HoldTime, true
+	}
+	return s.Received.HoldTime, true
+}
+
+// Sessions keeps track of the bgp session with each peer
+type Sessions struct {
+	sessions map[string]*Session
+	sync.RWMutex
+}
+
+// NewSessions returns an empty session table
+func NewSessions() *Sessions {
+	return &Sessions{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Update applies an open, state or End-of-RIB event to the session of its
+// peer, other events are ignored
+func (s *Sessions) Update(evt *Event) {
+	isState := evt.Type == "state" && evt.Peer.State != ""
+	if evt.Open == nil && !isState && evt.EOR == "" {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	session, ok := s.sessions[evt.Peer.IP]
+	if !ok {
+		session = &Session{
+			DownReasons: make(map[string]int),
+			EORSent:     make(map[string]time.Time),
+			EORReceived: make(map[string]time.Time),
+		}
+		s.sessions[evt.Peer.IP] = session
+	}
+	session.Peer = Peer{IP: evt.Peer.IP, ASN: evt.Peer.ASN}
+	session.Self = evt.Self
+	if evt.Open != nil {
+		switch evt.Direction {
+		case "send":
+			session.Sent = evt.Open
+		case "receive":
+			session.Received = evt.Open
+		}
+	}
+	if isState {
+		session.Reason = evt.Peer.Reason
+	}
+	// every failed connection attempt is reported as down with its own reason
+	if isState && evt.Peer.State == "down" {
+		session.DownReasons[NormalizeDownReason(evt.Peer.Reason)]++
+	}
+	// only an actual change of state is recorded
+	if isState && evt.Peer.State != session.State {
+		if evt.Peer.State == "up" {
+			session.EstablishedTransitions++
+			session.Established = evt.Time.Time
+		}
+		session.State = evt.Peer.State
+		session.LastStateChange = evt.Time.Time
+		session.EORSent = make(map[string]time.Time)
+		session.EORReceived = make(map[string]time.Time)
+	}
+	if evt.EOR != "" {
+		eors := session.EORReceived
+		if evt.Direction == "send" {
+			eors = session.EORSent
+		}
+		if _, ok := eors[evt.EOR]; !ok {
+			eors[evt.EOR] = evt.Time.Time
+			// we may have been started after the session came up
+			if session.State == "up" && !session.Established.IsZero() {
+				evt.Convergence = evt.Time.Sub(session.Established)
+			}
+		}
+	}
+}
+
+// Session returns a snapshot of the session with a peer
+func (s *Sessions) Session(peer string) (Session, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	session, ok := s.sessions[peer]
+	if !ok {
+		return Session{}, false
+	}
+	return session.snapshot(), true
+}
+
+// Sessions returns a snapshot of all sessions sorted by peer
+func (s *Sessions) Sessions() []Session {
+	s.RLock()
+	defer s.RUnlock()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session.snapshot())
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Peer.IP < sessions[j].Peer.IP
+	})
+	return sessions
+}
+
+func (s *Session) snapshot() Session {
+	ss := *s
+	ss.DownReasons = make(map[string]int, len(s.DownReasons))
+	for reason, count := range s.DownReasons {
+		ss.DownReasons[reason] = count
+	}
+	ss.EORSent = make(map[string]time.Time, len(s.EORSent))
+	for family, t := range s.EORSent {
+		ss.EORSent[family] = t
+	}
+	ss.EORReceived = make(map[string]time.Time, len(s.EORReceived))
+	for family, t := range s.EORReceived {
+		ss.EORReceived[family] = t
+	}
+	return ss
+}
@@ -0,0 +1,143 @@
+package exabgp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testOpenSent = `{ "exabgp": "4.0.1", "time": 1554851439.3015678, "host" : "node1", "pid" : 9029, "ppid" : 1, "counter": 2, "type": "open", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "open": { "version": 4, "asn": 64496, "hold_time": 180, "router_id": "192.168.1.184", "capabilities": { "multiprotocol": { "name": "multiprotocol", "families": [ "ipv4/unicast", "ipv6/unicast" ] }, "asn4": { "name": "asn4", "asn4": 64496 }, "graceful-restart": { "name": "graceful restart", "time": 180, "address-family-flags": { "ipv4/unicast": [ "restart" ] }, "restart-flags": [ "forwarding" ] }, "route-refresh": { "name": "route-refresh", "variant": "RFC" }, "enhanced-route-refresh": { "name": "enhanced-route-refresh" }, "extended-message": { "name": "extended-message", "size": 65535 } } } } }`
+
+var testOpenReceived = `{ "exabgp": "4.0.1", "time": 1554851252.6501951, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 2, "type": "open", "header": "0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF003501", "body": "0x04FBF000B4C0A801021802060104000100010202800002020200020641040000FBF0", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive", "open": { "version": 4, "asn": 64496, "hold_time": 90, "router_id": "192.168.1.2", "capabilities": { "1": { "name": "multiprotocol", "families": [ "ipv4/unicast" ] }, "128": { "name": "route-refresh", "variant": "RFC" }, "2": { "name": "route-refresh", "variant": "RFC" }, "65": { "name": "asn4", "asn4": 64496 } } } } }`
+
+func TestParseOpen(t *testing.T) {
+	evt, err := ParseEvent([]byte(testOpenSent))
+	require.NoError(t, err)
+	require.NotNil(t, evt.Open)
+	require.Equal(t, 4, evt.Open.Version)
+	require.Equal(t, 64496, evt.Open.ASN)
+	require.Equal(t, 180, evt.Open.HoldTime)
+	require.Equal(t, "192.168.1.184", evt.Open.RouterID)
+	require.Equal(t, []string{"asn4", "enhanced-route-refresh", "extended-message", "graceful-restart", "multiprotocol", "route-refresh"}, evt.Open.Capabilities)
+	require.Equal(t, []string{"ipv4 unicast", "ipv6 unicast"}, evt.Open.Families)
+
+	evt, err = ParseEvent([]byte(testOpenReceived))
+	require.NoError(t, err)
+	// route-refresh is advertised under both its standard and pre-standard code
+	require.Equal(t, []string{"asn4", "multiprotocol", "route-refresh"}, evt.Open.Capabilities)
+	require.Equal(t, []string{"ipv4 unicast"}, evt.Open.Families)
+}
+
+func TestSessionsUpdate(t *testing.T) {
+	sessions := NewSessions()
+	evt, err := ParseEvent([]byte(testOpenSent))
+	require.NoError(t, err)
+	sessions.Update(evt)
+
+	ss := sessions.Sessions()
+	require.Len(t, ss, 1)
+	require.Equal(t, "192.168.1.2", ss[0].Peer.IP)
+	require.NotNil(t, ss[0].Sent)
+	require.Nil(t, ss[0].Received)
+	_, ok := ss[0].HoldTime()
+	require.False(t, ok)
+
+	evt, err = ParseEvent([]byte(testOpenReceived))
+	require.NoError(t, err)
+	sessions.Update(evt)
+
+	ss = sessions.Sessions()
+	require.Len(t, ss, 1)
+	require.Equal(t, "192.168.1.2", ss[0].Received.RouterID)
+	holdTime, ok := ss[0].HoldTime()
+	require.True(t, ok)
+	require.Equal(t, 90, holdTime)
+}
+
+func TestSessionsIgnoreOtherEvents(t *testing.T) {
+	sessions := NewSessions()
+	evt, err := ParseEvent([]byte(`{ "exabgp": "4.0.1", "time": 1554851252.6508179, "host" : "node1", "pid" : 8618, "ppid" : 1, "counter": 3, "type": "keepalive", "header": "0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "receive"  } }`))
+	require.NoError(t, err)
+	sessions.Update(evt)
+	require.Empty(t, sessions.Sessions())
+}
+
+func testSessionState(t *testing.T, sessions *Sessions, ts string, state string) {
+	evt, err := ParseEvent([]byte(`{ "exabgp": "4.0.1", "time": ` + ts + `, "host" : "node1", "pid" : 8059, "ppid" : 1, "counter": 25, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "` + state + `" } }`))
+	require.NoError(t, err)
+	sessions.Update(evt)
+}
+
+func TestSessionsStateHistory(t *testing.T) {
+	sessions := NewSessions()
+	testSessionState(t, sessions, "1554851000", "connected")
+	testSessionState(t, sessions, "1554851001", "up")
+	ss := sessions.Sessions()
+	require.Len(t, ss, 1)
+	require.Equal(t, "up", ss[0].State)
+	require.Equal(t, int64(1554851001), ss[0].LastStateChange.Unix())
+	require.Equal(t, 1, ss[0].EstablishedTransitions)
+
+	// a flap, the repeated down events of the reconnect attempts don't count as changes
+	testSessionState(t, sessions, "1554851100", "down")
+	testSessionState(t, sessions, "1554851110", "down")
+	ss = sessions.Sessions()
+	require.Equal(t, "down", ss[0].State)
+	require.Equal(t, int64(1554851100), ss[0].LastStateChange.Unix())
+
+	testSessionState(t, sessions, "1554851120", "connected")
+	testSessionState(t, sessions, "1554851121", "up")
+	ss = sessions.Sessions()
+	require.Equal(t, "up", ss[0].State)
+	require.Equal(t, int64(1554851121), ss[0].LastStateChange.Unix())
+	require.Equal(t, 2, ss[0].EstablishedTransitions)
+}
+
+func TestSessionsDownReasons(t *testing.T) {
+	sessions := NewSessions()
+	for _, line := range []string{
+		`{ "exabgp": "4.0.1", "time": 1555087685.177178, "host" : "node1", "pid" : 21053, "ppid" : 1, "counter": 3, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "down", "reason": "peer reset, message (notification received (2,7)) error(OPEN message error / Unsupported Capability / )" } }`,
+		`{ "exabgp": "4.0.1", "time": 1555087686.177178, "host" : "node1", "pid" : 21053, "ppid" : 1, "counter": 4, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "connected" } }`,
+		`{ "exabgp": "4.0.1", "time": 1555087687.2088819, "host" : "node1", "pid" : 21053, "ppid" : 1, "counter": 5, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "down", "reason": "peer reset, message (closing connection) error(the TCP connection was closed by the remote end)" } }`,
+		`{ "exabgp": "4.0.1", "time": 1555087688.2088819, "host" : "node1", "pid" : 21053, "ppid" : 1, "counter": 6, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "down", "reason": "peer reset, message (closing connection) error(the TCP connection was closed by the remote end)" } }`,
+	} {
+		evt, err := ParseEvent([]byte(line))
+		require.NoError(t, err)
+		sessions.Update(evt)
+	}
+	ss := sessions.Sessions()
+	require.Len(t, ss, 1)
+	require.Equal(t, map[string]int{
+		DownReasonNotificationReceived: 1,
+		DownReasonTCPClosed:            2,
+	}, ss[0].DownReasons)
+}
+
+func TestSessionsEOR(t *testing.T) {
+	sessions := NewSessions()
+	testSessionState(t, sessions, "1554843220", "connected")
+	testSessionState(t, sessions, "1554843221", "up")
+
+	evt, err := ParseEvent([]byte(`{ "exabgp": "4.0.1", "time": 1554843223.569906, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 12, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "eor": { "afi" : "ipv4", "safi" : "unicast" } } } }`))
+	require.NoError(t, err)
+	require.Equal(t, "ipv4 unicast", evt.EOR)
+	sessions.Update(evt)
+	require.Equal(t, 2569906*time.Microsecond, evt.Convergence.Round(time.Microsecond))
+
+	// only the first End-of-RIB of the session is the end of the convergence
+	evt, err = ParseEvent([]byte(`{ "exabgp": "4.0.1", "time": 1554843323.569906, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 13, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "eor": { "afi" : "ipv4", "safi" : "unicast" } } } }`))
+	require.NoError(t, err)
+	sessions.Update(evt)
+	require.Zero(t, evt.Convergence)
+
+	ss := sessions.Sessions()
+	require.Len(t, ss, 1)
+	require.Contains(t, ss[0].EORSent, "ipv4 unicast")
+	require.Empty(t, ss[0].EORReceived)
+
+	// a new session has to converge again
+	testSessionState(t, sessions, "1554843400", "down")
+	ss = sessions.Sessions()
+	require.Empty(t, ss[0].EORSent)
+}
@@ -0,0 +1,17 @@
+package exabgp
+
+// Signal represents a signal received by exabgp
+type Signal struct {
+	Code int
+	Name string
+}
+
+// IsReload reports whether the signal makes exabgp reload its configuration,
+// SIGUSR1 reloads it and SIGUSR2 also restarts the api processes
+func (s *Signal) IsReload() bool {
+	switch s.Name {
+	case "SIGUSR1", "SIGUSR2":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package exabgp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignal(t *testing.T) {
+	tc := map[string]struct {
+		line   string
+		code   int
+		name   string
+		reload bool
+	}{
+		"exabgp 4.0": {`{ "exabgp": "4.0.1", "time": 1555437135.581991, "host" : "fed111dfb0e7", "pid" : 22, "ppid" : 20, "counter": 5, "type": "signal", "neighbor": { "address": { "local": "127.0.0.1", "peer": "127.0.0.1" }, "asn": { "local": 64496, "peer": 64496 } , "name": "UNKNOWN", "code": "0" } }`, 0, "UNKNOWN", false},
+		"reload":     {`{ "exabgp": "4.2.4", "time": 1555437135.581991, "host" : "node1", "pid" : 22, "ppid" : 20, "counter": 5, "type": "signal", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "code": 10, "name": "SIGUSR1" } }`, 10, "SIGUSR1", true},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			evt, err := ParseEvent([]byte(test.line))
+			require.NoError(t, err)
+			require.NotNil(t, evt.Signal)
+			require.Equal(t, test.code, evt.Signal.Code)
+			require.Equal(t, test.name, evt.Signal.Name)
+			require.Equal(t, test.reload, evt.Signal.IsReload())
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package exporter
 
 import (
+	"strings"
+
 	"github.com/go-kit/log"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,14 +17,69 @@ var (
 	parseName         = `exporter_parse_failures`
 	totalScrapesName  = `exporter_total_scrapes`
 	totalScrapesHelp  = `current total exabgp scrapes`
+	unknownFamilyName = `exporter_unknown_family_total`
+	unknownFamilyHelp = `number of times routes were skipped because their family is not supported`
+	eventsName        = `exporter_events_received_total`
+	eventsHelp        = `number of events received from exabgp`
+	gapsName          = `exporter_event_sequence_gaps_total`
+	gapsHelp          = `number of times events from exabgp were lost or out of order`
+	cliDurationName   = `exporter_cli_duration_seconds`
+	cliDurationHelp   = `time spent running exabgp commands`
+	cliTimeoutsName   = `exporter_cli_timeouts_total`
+	cliTimeoutsHelp   = `number of exabgp commands which timed out`
+	lastRefreshName   = `exporter_last_refresh_timestamp_seconds`
+	lastRefreshHelp   = `unix timestamp of the last time exabgp was polled`
+	staleName         = `exporter_stale`
+	staleHelp         = `whether exabgp was not polled recently enough for the metrics to be trusted`
 	summaryHelp       = `shows the state of a bgp peer`
 	summaryLabelNames = []string{"peer_ip", "peer_asn"}
 	ribHelp           = `shows the state of a given nlri`
 	ribLabelNames     = []string{
 		"peer_ip", "peer_asn", "local_ip", "local_asn", "nlri", "family",
-		"med", "local_preference", "as_path", "communities",
+		"med", "local_preference", "as_path", "communities", "direction",
+		"rd", "label", "path_id", "route_target",
+	}
+	flowHelp       = `shows the state of a given flowspec rule`
+	flowLabelNames = []string{
+		"peer_ip", "peer_asn", "local_ip", "local_asn", "family", "rule",
+		"destination", "source", "protocol", "destination_port", "source_port",
+		"action", "direction",
+	}
+	// flowActions are the prefixes of the extended communities telling what
+	// to do with the traffic matching a flowspec rule, as exabgp prints them
+	flowActions    = []string{"rate-limit", "redirect", "copy-to-nexthop", "mark", "action"}
+	vplsHelp       = `shows the state of a given l2vpn vpls label block`
+	vplsLabelNames = []string{
+		"peer_ip", "peer_asn", "local_ip", "local_asn",
+		"rd", "endpoint", "base", "offset", "size", "direction",
 	}
-	exabgpUp = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "up"), "Was the last scrape of exabgp successful.", nil, nil)
+	notificationsHelp       = `number of bgp notification messages sent to or received from a peer`
+	notificationsLabelNames = []string{"peer_ip", "peer_asn", "direction", "code", "subcode"}
+	messagesHelp            = `number of bgp messages sent to or received from a peer by type`
+	messagesLabelNames      = []string{"peer_ip", "peer_asn", "direction", "type"}
+	capabilityHelp          = `shows a capability advertised in the open message sent to or received from a peer`
+	capabilityLabelNames    = []string{"peer_ip", "peer_asn", "direction", "capability"}
+	familyHelp              = `shows an address family advertised in the open message sent to or received from a peer`
+	familyLabelNames        = []string{"peer_ip", "peer_asn", "direction", "family"}
+	holdTimeHelp            = `hold time negotiated with a peer in seconds`
+	sessionInfoHelp         = `information about the session with a peer, always 1`
+	sessionInfoLabelNames   = []string{"peer_ip", "peer_asn", "local_ip", "local_asn", "local_router_id", "peer_router_id"}
+	stateChangeHelp         = `unix timestamp of the last state change of a bgp peer`
+	stateChangeLabelNames   = []string{"peer_ip", "peer_asn", "state"}
+	transitionsHelp         = `number of times the session with a bgp peer has been established`
+	downHelp                = `number of times a bgp peer went down by reason`
+	downLabelNames          = []string{"peer_ip", "peer_asn", "reason"}
+	eorReceivedHelp         = `shows the End-of-RIB of a family was received from a peer since the session came up`
+	eorSentHelp             = `shows the End-of-RIB of a family was sent to a peer since the session came up`
+	eorLabelNames           = []string{"peer_ip", "peer_asn", "family"}
+	convergenceHelp         = `time from the session with a peer coming up to the End-of-RIB of a family in seconds`
+	convergenceLabelNames   = []string{"direction", "family"}
+	infoHelp                = `information about the running exabgp, always 1`
+	infoLabelNames          = []string{"version", "host", "pid"}
+	signalsHelp             = `number of signals received by exabgp`
+	signalsLabelNames       = []string{"signal"}
+	lastReloadHelp          = `unix timestamp of the last configuration reload of exabgp`
+	exabgpUp                = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "up"), "Was the last scrape of exabgp successful.", nil, nil)
 )
 
 func newSummaryMetric(metricName string) *prometheus.Desc {
@@ -33,11 +90,103 @@ func newRibMetric(metricName string) *prometheus.Desc {
 	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "state", metricName), ribHelp, ribLabelNames, nil)
 }
 
+func newFlowMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "state", metricName), flowHelp, flowLabelNames, nil)
+}
+
+// flowAction returns the traffic actions among the extended communities of a
+// flowspec rule, the route targets and others are left out
+func flowAction(extendedCommunities []string) string {
+	actions := []string{}
+	for _, ec := range extendedCommunities {
+		for _, prefix := range flowActions {
+			if strings.HasPrefix(ec, prefix) {
+				actions = append(actions, ec)
+				break
+			}
+		}
+	}
+	return strings.Join(actions, " ")
+}
+
+func newVplsMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "state", metricName), vplsHelp, vplsLabelNames, nil)
+}
+
+func newMessagesMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), messagesHelp, messagesLabelNames, nil)
+}
+
+func newCapabilityMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), capabilityHelp, capabilityLabelNames, nil)
+}
+
+func newFamilyMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), familyHelp, familyLabelNames, nil)
+}
+
+func newHoldTimeMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), holdTimeHelp, summaryLabelNames, nil)
+}
+
+func newSessionInfoMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), sessionInfoHelp, sessionInfoLabelNames, nil)
+}
+
+func newStateChangeMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), stateChangeHelp, stateChangeLabelNames, nil)
+}
+
+func newTransitionsMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), transitionsHelp, summaryLabelNames, nil)
+}
+
+func newDownMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), downHelp, downLabelNames, nil)
+}
+
+func newEORReceivedMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), eorReceivedHelp, eorLabelNames, nil)
+}
+
+func newEORSentMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "peer", metricName), eorSentHelp, eorLabelNames, nil)
+}
+
+func newInfoMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), infoHelp, infoLabelNames, nil)
+}
+
+func newEventsMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), eventsHelp, nil, nil)
+}
+
+func newGapsMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), gapsHelp, nil, nil)
+}
+
+func newLastRefreshMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), lastRefreshHelp, nil, nil)
+}
+
+func newStaleMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), staleHelp, nil, nil)
+}
+
+func newSignalsMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), signalsHelp, signalsLabelNames, nil)
+}
+
+func newLastReloadMetric(metricName string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), lastReloadHelp, nil, nil)
+}
+
 // BaseExporter is common data between the two types of exporters
 type BaseExporter struct {
 	up            prometheus.Gauge
 	totalScrapes  prometheus.Counter
 	parseFailures prometheus.Counter
+	unknownFamily *prometheus.CounterVec
 	logger        log.Logger
 }
 
@@ -59,6 +208,11 @@ func NewBaseExporter(logger log.Logger) BaseExporter {
 			Name:      parseName,
 			Help:      parseHelp,
 		}),
+		unknownFamily: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      unknownFamilyName,
+			Help:      unknownFamilyHelp,
+		}, []string{"family"}),
 		logger: logger,
 	}
 }
@@ -69,6 +223,7 @@ func (e *BaseExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- exabgpUp
 	ch <- e.totalScrapes.Desc()
 	ch <- e.parseFailures.Desc()
+	e.unknownFamily.Describe(ch)
 }
 
 func (e *BaseExporter) setExabgpStatus(ch chan<- prometheus.Metric, i int) {
@@ -7,22 +7,32 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp"
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages"
 )
 
 type EmbeddedExporter struct {
-	mutex   sync.RWMutex
-	summary *prometheus.GaugeVec
-	rib     *prometheus.GaugeVec
+	mutex         sync.RWMutex
+	summary       *prometheus.GaugeVec
+	notifications *prometheus.CounterVec
+	messages      *prometheus.CounterVec
+	convergence   *prometheus.HistogramVec
+	rib           *exabgp.RIB
+	parser        *exabgp.Parser
+	timeout       time.Duration
+	done          chan struct{}
 	BaseExporter
 }
 
-func NewEmbeddedExporter(logger log.Logger) (*EmbeddedExporter, error) {
+// NewEmbeddedExporter returns an exporter reading events from exabgp, exabgp
+// is considered down when no event was read for timeout (0 disables it)
+func NewEmbeddedExporter(timeout time.Duration, logger log.Logger) (*EmbeddedExporter, error) {
 	be := NewBaseExporter(logger)
 	be.up.Set(float64(1))
 
@@ -32,36 +42,59 @@ func NewEmbeddedExporter(logger log.Logger) (*EmbeddedExporter, error) {
 		Subsystem: "state",
 		Help:      summaryHelp,
 	}, summaryLabelNames)
-	rm := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name:      "route",
-		Namespace: namespace,
-		Subsystem: "state",
-		Help:      ribHelp,
-	}, ribLabelNames)
 
 	prometheus.MustRegister(sm)
-	prometheus.MustRegister(rm)
 	return &EmbeddedExporter{
-		summary:      sm,
-		rib:          rm,
+		summary: sm,
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:      "notifications_total",
+			Namespace: namespace,
+			Subsystem: "peer",
+			Help:      notificationsHelp,
+		}, notificationsLabelNames),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:      "messages_total",
+			Namespace: namespace,
+			Subsystem: "peer",
+			Help:      messagesHelp,
+		}, messagesLabelNames),
+		convergence: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:      "convergence_seconds",
+			Namespace: namespace,
+			Subsystem: "peer",
+			Help:      convergenceHelp,
+			// from a handful of routes to full tables
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, convergenceLabelNames),
+		rib:          exabgp.NewRIB(),
+		parser:       exabgp.NewParser(),
+		timeout:      timeout,
+		done:         make(chan struct{}),
 		BaseExporter: be,
 	}, nil
 }
 
-// Run starts the background reader for populating metrics
+// Run starts the background reader for populating metrics, it stops once
+// the reader is closed or fails
 func (e *EmbeddedExporter) Run(reader *bufio.Reader) {
 	go func() {
+		defer close(e.done)
 		for {
 			line, _, err := reader.ReadLine()
-			if err != nil && err != io.EOF {
+			if err != nil {
+				// exabgp went away (EOF) or the pipe broke, nothing more will come
+				reason := "event stream closed"
+				if err != io.EOF {
+					reason = fmt.Sprintf("event stream failed: %s", err)
+				}
 				// nolint:errcheck
 				level.Error(e.BaseExporter.logger).Log(
-					"msg", "unknown error", "err", err,
+					"msg", "stopped reading events", "reason", reason,
 				)
-				e.BaseExporter.parseFailures.Inc()
-				continue
+				e.parser.SetStatus(exabgp.StatusDown, reason)
+				return
 			}
-			evt, err := exabgp.ParseEvent(line)
+			evt, err := e.parser.Parse(line)
 			if err != nil {
 				// nolint:errcheck
 				level.Error(e.BaseExporter.logger).Log(
@@ -70,76 +103,78 @@ func (e *EmbeddedExporter) Run(reader *bufio.Reader) {
 				e.BaseExporter.parseFailures.Inc()
 				continue
 			}
+			if evt.Restarted {
+				// nolint:errcheck
+				level.Info(e.BaseExporter.logger).Log(
+					"msg", "exabgp restarted, dropping its routes", "pid", evt.PID,
+				)
+				e.rib.Reset()
+			}
+			for _, family := range evt.UnknownFamilies {
+				// nolint:errcheck
+				level.Debug(e.BaseExporter.logger).Log(
+					"msg", "unable to handle family", "family", family,
+				)
+				e.BaseExporter.unknownFamily.WithLabelValues(family).Inc()
+			}
+			for family, err := range evt.FamilyErrors {
+				// nolint:errcheck
+				level.Error(e.BaseExporter.logger).Log(
+					"msg", "unable to parse routes", "family", family, "err", err,
+				)
+				e.BaseExporter.parseFailures.Inc()
+			}
 			var labels = map[string]string{
 				"peer_ip":  evt.Peer.IP,
 				"peer_asn": fmt.Sprintf("%d", evt.Peer.ASN),
 			}
+			if evt.IsMessage() {
+				e.messages.WithLabelValues(
+					evt.Peer.IP, fmt.Sprintf("%d", evt.Peer.ASN), evt.Direction, evt.Type,
+				).Inc()
+			}
+			if evt.Convergence > 0 {
+				e.convergence.WithLabelValues(evt.Direction, evt.EOR).Observe(evt.Convergence.Seconds())
+			}
+			if n := evt.Notification; n != nil {
+				// nolint:errcheck
+				level.Info(e.BaseExporter.logger).Log(
+					"msg", "bgp notification",
+					"peer_ip", evt.Peer.IP,
+					"direction", evt.Direction,
+					"code", n.CodeName(),
+					"subcode", n.SubCodeName(),
+					"data", n.Data,
+				)
+				e.notifications.WithLabelValues(
+					evt.Peer.IP, fmt.Sprintf("%d", evt.Peer.ASN), evt.Direction,
+					n.CodeName(), n.SubCodeName(),
+				).Inc()
+			}
 			switch evt.Peer.State {
 			case "down":
+				// nolint:errcheck
+				level.Info(e.BaseExporter.logger).Log(
+					"msg", "peer down", "peer_ip", evt.Peer.IP, "reason", evt.Peer.Reason,
+				)
 				e.summary.With(labels).Set(float64(0))
+				e.rib.WithdrawPeer(evt.Peer.IP)
 			default:
 				e.summary.With(labels).Set(float64(1))
 			}
-			if evt.Direction == "send" {
-				announcements := evt.GetAnnouncements()
-				if announcements != nil {
-					labels["local_ip"] = evt.Self.IP
-					labels["local_asn"] = fmt.Sprintf("%d", evt.Self.ASN)
-					for _, v := range announcements.IPV4Unicast {
-						labels["communities"] = communityToString(v.Attributes.Community)
-						labels["as_path"] = asPathToString(v.Attributes.ASPath)
-						labels["local_preference"] = strconv.Itoa(v.Attributes.LocalPreference)
-						labels["med"] = strconv.Itoa(int(v.Attributes.Med))
-						labels["family"] = "ipv4 unicast"
-						for _, r := range v.NLRI {
-							labels["nlri"] = r
-							e.rib.With(labels).Set(float64(1))
-						}
-					}
-					for _, v := range announcements.IPV6Unicast {
-						labels["communities"] = communityToString(v.Attributes.Community)
-						labels["as_path"] = asPathToString(v.Attributes.ASPath)
-						labels["local_preference"] = strconv.Itoa(v.Attributes.LocalPreference)
-						labels["med"] = strconv.Itoa(int(v.Attributes.Med))
-						labels["family"] = "ipv6 unicast"
-						for _, r := range v.NLRI {
-							labels["nlri"] = r
-							e.rib.With(labels).Set(float64(1))
-						}
-					}
-				}
-				withdraws := evt.GetWithdrawals()
-				if withdraws != nil {
-					labels["local_ip"] = evt.Self.IP
-					labels["local_asn"] = fmt.Sprintf("%d", evt.Self.ASN)
-					for _, w := range withdraws.IPv4Unicast {
-						labels["communities"] = communityToString(w.Attributes.Community)
-						labels["as_path"] = asPathToString(w.Attributes.ASPath)
-						labels["local_preference"] = strconv.Itoa(w.Attributes.LocalPreference)
-						labels["med"] = strconv.Itoa(int(w.Attributes.Med))
-						for _, r := range w.NLRI {
-							labels["family"] = "ipv4 unicast"
-							labels["nlri"] = r
-							e.rib.With(labels).Set(float64(0))
-						}
-					}
-					for _, w := range withdraws.IPv6Unicast {
-						labels["communities"] = communityToString(w.Attributes.Community)
-						labels["as_path"] = asPathToString(w.Attributes.ASPath)
-						labels["local_preference"] = strconv.Itoa(w.Attributes.LocalPreference)
-						labels["med"] = strconv.Itoa(int(w.Attributes.Med))
-						for _, r := range w.NLRI {
-							labels["family"] = "ipv6 unicast"
-							labels["nlri"] = r
-							e.rib.With(labels).Set(float64(0))
-						}
-					}
-				}
+			switch evt.Direction {
+			case "send", "receive":
+				e.rib.Update(evt)
 			}
 		}
 	}()
 }
 
+// Done returns a channel which is closed once Run stopped reading events
+func (e *EmbeddedExporter) Done() <-chan struct{} {
+	return e.done
+}
+
 // Collect delivers all seen stats as Prometheus metrics
 // It implements prometheus.Collector.
 func (e *EmbeddedExporter) Collect(ch chan<- prometheus.Metric) {
@@ -148,13 +183,155 @@ func (e *EmbeddedExporter) Collect(ch chan<- prometheus.Metric) {
 	e.BaseExporter.totalScrapes.Inc()
 	ch <- e.BaseExporter.totalScrapes
 	ch <- e.BaseExporter.parseFailures
+	// exabgp runs the exporter so it is up unless it told us it is going away,
+	// stopped talking to us or went quiet for too long
+	stale := e.timeout > 0 && time.Since(e.parser.LastSeen()) > e.timeout
+	if e.parser.Status() == exabgp.StatusDown || stale {
+		e.BaseExporter.up.Set(float64(0))
+	} else {
+		e.BaseExporter.up.Set(float64(1))
+	}
 	ch <- e.BaseExporter.up
+	e.BaseExporter.unknownFamily.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(newEventsMetric(eventsName), prometheus.CounterValue, float64(e.parser.EventsReceived()))
+	ch <- prometheus.MustNewConstMetric(newGapsMetric(gapsName), prometheus.CounterValue, float64(e.parser.SequenceGaps()))
+	e.notifications.Collect(ch)
+	e.messages.Collect(ch)
+	e.convergence.Collect(ch)
+
+	if instance, ok := e.parser.Instance(); ok {
+		ch <- prometheus.MustNewConstMetric(
+			newInfoMetric("info"), prometheus.GaugeValue, float64(1),
+			instance.Version, instance.Host, strconv.Itoa(instance.PID),
+		)
+	}
+	signalsDesc := newSignalsMetric("signals_total")
+	for signal, count := range e.parser.Signals() {
+		ch <- prometheus.MustNewConstMetric(signalsDesc, prometheus.CounterValue, float64(count), signal)
+	}
+	if lastReload := e.parser.LastReload(); !lastReload.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			newLastReloadMetric("last_reload_timestamp_seconds"), prometheus.GaugeValue, float64(lastReload.Unix()),
+		)
+	}
+
+	capabilityDesc := newCapabilityMetric("capability_info")
+	familyDesc := newFamilyMetric("family_info")
+	holdTimeDesc := newHoldTimeMetric("hold_time_seconds")
+	stateChangeDesc := newStateChangeMetric("last_state_change_timestamp_seconds")
+	transitionsDesc := newTransitionsMetric("established_transitions_total")
+	downDesc := newDownMetric("down_total")
+	eorReceivedDesc := newEORReceivedMetric("eor_received")
+	eorSentDesc := newEORSentMetric("eor_sent")
+	for _, s := range e.parser.Sessions().Sessions() {
+		if !s.LastStateChange.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				stateChangeDesc, prometheus.GaugeValue, float64(s.LastStateChange.Unix()), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+				s.State,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				transitionsDesc, prometheus.CounterValue, float64(s.EstablishedTransitions), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+			)
+		}
+		for reason, count := range s.DownReasons {
+			ch <- prometheus.MustNewConstMetric(
+				downDesc, prometheus.CounterValue, float64(count), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+				reason,
+			)
+		}
+		for family := range s.EORReceived {
+			ch <- prometheus.MustNewConstMetric(
+				eorReceivedDesc, prometheus.GaugeValue, float64(1), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+				family,
+			)
+		}
+		for family := range s.EORSent {
+			ch <- prometheus.MustNewConstMetric(
+				eorSentDesc, prometheus.GaugeValue, float64(1), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+				family,
+			)
+		}
+		for direction, open := range map[string]*exabgp.Open{"send": s.Sent, "receive": s.Received} {
+			if open == nil {
+				continue
+			}
+			for _, c := range open.Capabilities {
+				ch <- prometheus.MustNewConstMetric(
+					capabilityDesc, prometheus.GaugeValue, float64(1), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+					direction, c,
+				)
+			}
+			for _, f := range open.Families {
+				ch <- prometheus.MustNewConstMetric(
+					familyDesc, prometheus.GaugeValue, float64(1), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+					direction, f,
+				)
+			}
+		}
+		if holdTime, ok := s.HoldTime(); ok {
+			ch <- prometheus.MustNewConstMetric(
+				holdTimeDesc, prometheus.GaugeValue, float64(holdTime), s.Peer.IP, strconv.Itoa(s.Peer.ASN),
+			)
+		}
+	}
+
+	desc := newRibMetric("route")
+	flowDesc := newFlowMetric("flow")
+	vplsDesc := newVplsMetric("vpls")
+	for _, r := range e.rib.Routes() {
+		isUp := 1
+		if r.Withdrawn {
+			isUp = 0
+		}
+		if r.Flow != nil {
+			ch <- prometheus.MustNewConstMetric(
+				flowDesc, prometheus.GaugeValue, float64(isUp), r.Peer.IP, strconv.Itoa(r.Peer.ASN),
+				r.Self.IP, strconv.Itoa(r.Self.ASN), r.Family, r.NLRI,
+				strings.Join(r.Flow.Destination, " "),
+				strings.Join(r.Flow.Source, " "),
+				strings.Join(r.Flow.Protocol, " "),
+				strings.Join(r.Flow.DestinationPort, " "),
+				strings.Join(r.Flow.SourcePort, " "),
+				flowAction(extendedCommunityToStrings(r.Attributes.ExtendedCommunity)),
+				r.Direction,
+			)
+			continue
+		}
+		if r.VPLS != nil {
+			ch <- prometheus.MustNewConstMetric(
+				vplsDesc, prometheus.GaugeValue, float64(isUp), r.Peer.IP, strconv.Itoa(r.Peer.ASN),
+				r.Self.IP, strconv.Itoa(r.Self.ASN), r.VPLS.RD,
+				strconv.Itoa(r.VPLS.Endpoint),
+				strconv.Itoa(r.VPLS.Base),
+				strconv.Itoa(r.VPLS.Offset),
+				strconv.Itoa(r.VPLS.Size),
+				r.Direction,
+			)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			desc, prometheus.GaugeValue, float64(isUp), r.Peer.IP, strconv.Itoa(r.Peer.ASN),
+			r.Self.IP, strconv.Itoa(r.Self.ASN), r.NLRI, r.Family,
+			strconv.Itoa(int(r.Attributes.Med)),
+			strconv.Itoa(r.Attributes.LocalPreference),
+			asPathToString(r.Attributes.ASPath),
+			communityToString(r.Attributes.Community),
+			r.Direction,
+			r.RD,
+			labelsToString(r.Labels),
+			r.PathID,
+			strings.Join(r.RouteTargets(), " "),
+		)
+	}
 }
 
 // Describe describes all the metrics ever exported by the exabgp exporter
 // It implements prometheus.Collector
 func (e *EmbeddedExporter) Describe(ch chan<- *prometheus.Desc) {
 	e.BaseExporter.Describe(ch)
+	e.notifications.Describe(ch)
+	e.messages.Describe(ch)
+	e.convergence.Describe(ch)
 }
 
 // Transform communities to string
@@ -169,6 +346,26 @@ func communityToString(communityAttribute [][]int) string {
 	return strings.Join(communityStrings, " ")
 }
 
+// Transform extended communities to strings
+func extendedCommunityToStrings(extendedCommunityAttribute []messages.ExtendedCommunity) []string {
+	extendedCommunityStrings := []string{}
+	for _, extendedCommunity := range extendedCommunityAttribute {
+		extendedCommunityStrings = append(extendedCommunityStrings, extendedCommunity.String)
+	}
+
+	return extendedCommunityStrings
+}
+
+// Transform mpls labels to string
+func labelsToString(labels []int) string {
+	labelStrings := []string{}
+	for _, label := range labels {
+		labelStrings = append(labelStrings, strconv.Itoa(label))
+	}
+
+	return strings.Join(labelStrings, " ")
+}
+
 // Transform ASPath to string
 func asPathToString(asPathAttribute []int) string {
 	asPathStrings := []string{}
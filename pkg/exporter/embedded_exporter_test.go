@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var testEventsDataFile = filepath.Join("..", "exabgp", "testdata", "exabgp.log")
+
+// testEvents returns the first events of the test data, the session with
+// 192.168.1.2 comes up, a route is announced to and received from the peer
+// then withdrawn
+func testEvents(t *testing.T, n int) []string {
+	file, err := os.ReadFile(testEventsDataFile)
+	require.NoError(t, err)
+	lines := strings.Split(string(file), "\n")
+	require.GreaterOrEqual(t, len(lines), n)
+	return lines[:n]
+}
+
+// testStream feeds events to the exporter and waits for them to be parsed
+type testStream struct {
+	t      *testing.T
+	e      *EmbeddedExporter
+	w      *io.PipeWriter
+	events int
+}
+
+func newTestStream(t *testing.T, e *EmbeddedExporter) *testStream {
+	r, w := io.Pipe()
+	e.Run(bufio.NewReader(r))
+	t.Cleanup(func() {
+		w.Close()
+		<-e.Done()
+	})
+	return &testStream{t: t, e: e, w: w}
+}
+
+func (s *testStream) send(lines ...string) {
+	for _, line := range lines {
+		_, err := io.WriteString(s.w, line+"\n")
+		require.NoError(s.t, err)
+		s.events++
+	}
+	require.Eventually(s.t, func() bool {
+		return s.e.parser.EventsReceived() == s.events
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEmbeddedCollect(t *testing.T) {
+	e, err := NewEmbeddedExporter(0, log.NewNopLogger())
+	require.NoError(t, err)
+	stream := newTestStream(t, e)
+	// the routes are not described up front
+	collector := testCollector(e.Collect)
+	events := testEvents(t, 6)
+	metrics := []string{
+		"exabgp_state_route", "exabgp_state_flow", "exabgp_state_vpls",
+		"exabgp_peer_eor_sent", "exabgp_peer_established_transitions_total",
+	}
+	// route is the state of the route sent to the peer, routes the one of
+	// the others
+	expected := func(route int, routes int, eor string) string {
+		return `# HELP exabgp_peer_established_transitions_total ` + transitionsHelp + `
+# TYPE exabgp_peer_established_transitions_total counter
+exabgp_peer_established_transitions_total{peer_asn="64496",peer_ip="192.168.1.2"} 1
+# HELP exabgp_state_flow ` + flowHelp + `
+# TYPE exabgp_state_flow gauge
+exabgp_state_flow{action="rate-limit:0",destination="10.0.0.1/32",destination_port="=80 =443",direction="send",family="ipv4 flow",local_asn="64496",local_ip="192.168.1.184",peer_asn="64496",peer_ip="192.168.1.2",protocol="=tcp",rule="flow destination-ipv4 10.0.0.1/32 source-ipv4 192.0.2.0/24 protocol =tcp destination-port [ =80 =443 ]",source="192.0.2.0/24",source_port=""} ` + strconv.Itoa(routes) + `
+# HELP exabgp_state_route ` + ribHelp + `
+# TYPE exabgp_state_route gauge
+exabgp_state_route{as_path="",communities="",direction="receive",family="ipv4 unicast",label="",local_asn="64496",local_ip="192.168.1.184",local_preference="100",med="0",nlri="0.0.0.0/0",path_id="",peer_asn="64496",peer_ip="192.168.1.2",rd="",route_target=""} ` + strconv.Itoa(routes) + `
+exabgp_state_route{as_path="",communities="",direction="send",family="ipv4 unicast",label="",local_asn="64496",local_ip="192.168.1.184",local_preference="100",med="100",nlri="192.168.88.2/32",path_id="",peer_asn="64496",peer_ip="192.168.1.2",rd="",route_target=""} ` + strconv.Itoa(route) + `
+# HELP exabgp_state_vpls ` + vplsHelp + `
+# TYPE exabgp_state_vpls gauge
+exabgp_state_vpls{base="10702",direction="send",endpoint="5",local_asn="64496",local_ip="192.168.1.184",offset="1",peer_asn="64496",peer_ip="192.168.1.2",rd="192.168.201.1:123",size="8"} ` + strconv.Itoa(routes) + `
+` + eor
+	}
+	eor := `# HELP exabgp_peer_eor_sent ` + eorSentHelp + `
+# TYPE exabgp_peer_eor_sent gauge
+exabgp_peer_eor_sent{family="ipv4 unicast",peer_asn="64496",peer_ip="192.168.1.2"} 1
+`
+	peer := func(up int) string {
+		return `# HELP exabgp_state_peer ` + summaryHelp + `
+# TYPE exabgp_state_peer gauge
+exabgp_state_peer{peer_asn="64496",peer_ip="192.168.1.2"} ` + strconv.Itoa(up) + `
+`
+	}
+
+	// the session comes up, routes are announced to and received from the peer
+	stream.send(events[:5]...)
+	stream.send(
+		`{ "exabgp": "4.0.1", "time": 1554843225.0377939, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 14, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100, "extended-community": [ { "value": 9225060887780392960, "string": "rate-limit:0" } ] }, "announce": { "ipv4 flow": { "no-nexthop": [ { "destination-ipv4": [ "10.0.0.1/32" ], "source-ipv4": [ "192.0.2.0/24" ], "protocol": [ "=tcp" ], "destination-port": [ "=80", "=443" ], "string": "flow destination-ipv4 10.0.0.1/32 source-ipv4 192.0.2.0/24 protocol =tcp destination-port [ =80 =443 ]" } ] } } } } } }`,
+		`{ "exabgp": "4.0.1", "time": 1554843226.0336444, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 15, "type": "update", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "direction": "send", "message": { "update": { "attribute": { "origin": "igp", "local-preference": 100 }, "announce": { "l2vpn vpls": { "192.168.201.1": [ { "rd": "192.168.201.1:123", "endpoint": 5, "base": 10702, "offset": 1, "size": 8 } ] } } } } } }`,
+	)
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected(1, 1, eor)), metrics...))
+	require.NoError(t, testutil.CollectAndCompare(e.summary, strings.NewReader(peer(1))))
+
+	// the route sent to the peer is withdrawn, every scrape sees it
+	stream.send(events[5])
+	for i := 0; i < 2; i++ {
+		require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected(0, 1, eor)), metrics...))
+	}
+
+	// the session goes down, all routes exchanged with the peer are withdrawn
+	stream.send(
+		`{ "exabgp": "4.0.1", "time": 1554843300.9878001, "host" : "node1", "pid" : 31372, "ppid" : 1, "counter": 16, "type": "state", "neighbor": { "address": { "local": "192.168.1.184", "peer": "192.168.1.2" }, "asn": { "local": 64496, "peer": 64496 } , "state": "down", "reason": "peer reset, message (notification received (2,7)) error(OPEN message error / Unsupported Capability /    )" } }`,
+	)
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected(0, 0, "")), metrics...))
+	require.NoError(t, testutil.CollectAndCompare(e.summary, strings.NewReader(peer(0))))
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testCLI writes a fake exabgpcli running the script
+func testCLI(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "exabgpcli")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	return path
+}
+
+func TestRunExaBGPCLITimeout(t *testing.T) {
+	e := testStandaloneExporter(t)
+	// the child keeps stdout open after exabgpcli itself is killed
+	e.ExaBGPCLI = testCLI(t, "sleep 30 &\nsleep 30")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := e.runExaBGPCLI(ctx, showSummarySubcommand)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, float64(1), testutil.ToFloat64(e.cliTimeouts))
+}
+
+func TestRunExaBGPCLI(t *testing.T) {
+	e := testStandaloneExporter(t)
+	e.ExaBGPCLI = testCLI(t, `echo "$@"`)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := e.runExaBGPCLI(ctx, showSummarySubcommand)
+	require.NoError(t, err)
+	require.Equal(t, "--root /etc/exabgp show neighbor summary\n", string(out))
+	require.Equal(t, float64(0), testutil.ToFloat64(e.cliTimeouts))
+}
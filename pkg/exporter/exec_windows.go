@@ -0,0 +1,9 @@
+//go:build windows
+
+package exporter
+
+import "os/exec"
+
+// killProcessGroup leaves it to exec to kill the command when its context
+// is done, there are no process groups to kill
+func killProcessGroup(cmd *exec.Cmd) {}
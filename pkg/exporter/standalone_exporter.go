@@ -2,28 +2,72 @@ package exporter
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/client"
 	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/messages/text"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 var (
-	showAdjRibSubcommand  = []string{"show", "adj-rib", "out", "extensive"}
-	showSummarySubcommand = []string{"show", "neighbor", "summary"}
+	showAdjRibOutSubcommand = []string{"show", "adj-rib", "out", "extensive"}
+	showAdjRibInSubcommand  = []string{"show", "adj-rib", "in", "extensive"}
+	showSummarySubcommand   = []string{"show", "neighbor", "summary"}
+	showNeighborSubcommand  = []string{"show", "neighbor", "extensive"}
+	versionSubcommand       = []string{"version"}
+)
+
+const (
+	// defaultScrapeTimeout is the prometheus default, used when the scrape
+	// doesn't tell its timeout
+	defaultScrapeTimeout = 10 * time.Second
+	// scrapeTimeoutOffset leaves time to send the metrics before prometheus
+	// gives up on the scrape
+	scrapeTimeoutOffset = 500 * time.Millisecond
 )
 
 // StandaloneExporter is a prometheus exporter that gathers metrics via calling exabgpcli
 type StandaloneExporter struct {
 	ExaBGPCLI  string
 	ExaBGPRoot string
-	mutex      sync.RWMutex
+	// Client talks to exabgp directly when set, instead of running exabgpcli
+	Client client.Client
+	// Timeout bounds the time spent querying exabgp in a scrape, when it is
+	// 0 the timeout of the scrape is used
+	Timeout time.Duration
+	// AdjRibIn also exports the routes received from peers
+	AdjRibIn bool
+	// NeighborExtensive queries show neighbor extensive instead of the
+	// summary, for the counters and settings of each session
+	NeighborExtensive bool
+	// Interval makes Run poll exabgp in the background, scrapes then serve
+	// the last results instead of querying exabgp
+	Interval    time.Duration
+	cliDuration *prometheus.HistogramVec
+	cliTimeouts prometheus.Counter
+	snapshot    []prometheus.Metric
+	lastRefresh time.Time
+	// stateChanges keeps the state change estimated for each peer, exabgp
+	// only tells how long ago it was to the second
+	stateChanges map[string]stateChange
+	// version is cached until exabgp stops answering, it may then have been
+	// upgraded
+	version       string
+	snapshotMutex sync.RWMutex
+	mutex         sync.RWMutex
+	done          chan struct{}
 	BaseExporter
 }
 
@@ -31,29 +75,218 @@ type StandaloneExporter struct {
 func NewStandaloneExporter(exabgpcli string, exabgproot string, logger log.Logger) (*StandaloneExporter, error) {
 	be := NewBaseExporter(logger)
 	return &StandaloneExporter{
-		ExaBGPCLI:    exabgpcli,
-		ExaBGPRoot:   exabgproot,
+		ExaBGPCLI:  exabgpcli,
+		ExaBGPRoot: exabgproot,
+		cliDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      cliDurationName,
+			Help:      cliDurationHelp,
+		}, []string{"command"}),
+		cliTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      cliTimeoutsName,
+			Help:      cliTimeoutsHelp,
+		}),
+		stateChanges: make(map[string]stateChange),
+		done:         make(chan struct{}),
 		BaseExporter: be,
 	}, nil
 }
 
+type stateChange struct {
+	state string
+	at    time.Time
+}
+
+// lastStateChange returns when a peer changed to its state given how long
+// ago that was. The estimate from the previous poll is kept while they are
+// within a second of each other, otherwise it would jitter with the time of
+// each poll.
+func (e *StandaloneExporter) lastStateChange(peer string, state string, ago time.Duration) time.Time {
+	at := time.Now().Add(-ago).Truncate(time.Second)
+	if previous, ok := e.stateChanges[peer]; ok && previous.state == state {
+		if diff := at.Sub(previous.at); diff >= -time.Second && diff <= time.Second {
+			return previous.at
+		}
+	}
+	e.stateChanges[peer] = stateChange{state: state, at: at}
+	return at
+}
+
 // Describe describes all the metrics ever exported by the exabgp exporter
 // It implements prometheus.Collector
 func (e *StandaloneExporter) Describe(ch chan<- *prometheus.Desc) {
 	e.BaseExporter.Describe(ch)
+	e.cliDuration.Describe(ch)
+	ch <- e.cliTimeouts.Desc()
 }
 
 // Collect fetches the stats from configured exabpcli command and delivers them
 // as Prometheus metrics. It implements prometheus.Collector.
 func (e *StandaloneExporter) Collect(ch chan<- prometheus.Metric) {
-	e.mutex.Lock() // To protect metrics from concurrent collects.
-	defer e.mutex.Unlock()
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = defaultScrapeTimeout - scrapeTimeoutOffset
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	e.collect(ctx, ch)
+}
+
+// Handler serves the metrics of the exporter along with the ones of the
+// default registry, exabgp is queried within the timeout of each scrape
+func (e *StandaloneExporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), e.scrapeTimeout(r))
+		defer cancel()
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(&scrapeCollector{ctx: ctx, exporter: e})
+		gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// scrapeTimeout returns the configured timeout or the one prometheus tells
+// in the scrape, minus some time to send the metrics
+func (e *StandaloneExporter) scrapeTimeout(r *http.Request) time.Duration {
+	if e.Timeout != 0 {
+		return e.Timeout
+	}
+	timeout := defaultScrapeTimeout
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			// nolint:errcheck
+			level.Debug(e.BaseExporter.logger).Log(
+				"msg", "unable to parse scrape timeout", "value", v, "err", err,
+			)
+		} else {
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	if timeout > 2*scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+	return timeout
+}
+
+// scrapeCollector collects the exporter within the context of a scrape
+type scrapeCollector struct {
+	ctx      context.Context
+	exporter *StandaloneExporter
+}
+
+func (c *scrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.exporter.Describe(ch)
+}
+
+func (c *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.exporter.collect(c.ctx, ch)
+}
+
+func (e *StandaloneExporter) collect(ctx context.Context, ch chan<- prometheus.Metric) {
 	e.BaseExporter.totalScrapes.Inc()
-	ribs, peers, err := e.scrape(ch)
+	if e.Interval > 0 {
+		e.collectSnapshot(ch)
+	} else {
+		e.mutex.Lock() // To protect metrics from concurrent collects.
+		e.poll(ctx, ch)
+		e.mutex.Unlock()
+	}
+
+	ch <- e.BaseExporter.totalScrapes
+	ch <- e.BaseExporter.parseFailures
+	e.BaseExporter.unknownFamily.Collect(ch)
+	e.cliDuration.Collect(ch)
+	ch <- e.cliTimeouts
+}
+
+// Run starts polling exabgp in the background every Interval until the
+// context is done, scrapes then serve the metrics of the last poll
+func (e *StandaloneExporter) Run(ctx context.Context) {
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.Interval)
+		defer ticker.Stop()
+		for {
+			e.refresh(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Done is closed once Run stopped polling exabgp
+func (e *StandaloneExporter) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *StandaloneExporter) refresh(ctx context.Context) {
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = e.Interval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+	e.mutex.Lock()
+	e.poll(ctx, ch)
+	e.mutex.Unlock()
+	close(ch)
+	<-done
+
+	e.snapshotMutex.Lock()
+	defer e.snapshotMutex.Unlock()
+	e.snapshot = metrics
+	e.lastRefresh = time.Now()
+}
+
+// collectSnapshot delivers the metrics of the last poll and how fresh they are
+func (e *StandaloneExporter) collectSnapshot(ch chan<- prometheus.Metric) {
+	e.snapshotMutex.RLock()
+	defer e.snapshotMutex.RUnlock()
+	for _, m := range e.snapshot {
+		ch <- m
+	}
+	stale := 1
+	if !e.lastRefresh.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			newLastRefreshMetric(lastRefreshName), prometheus.GaugeValue, float64(e.lastRefresh.Unix()),
+		)
+		// a poll takes at most an interval unless a timeout is set
+		maxAge := 2 * e.Interval
+		if e.Timeout > e.Interval {
+			maxAge = e.Interval + e.Timeout
+		}
+		if time.Since(e.lastRefresh) <= maxAge {
+			stale = 0
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(newStaleMetric(staleName), prometheus.GaugeValue, float64(stale))
+}
+
+// poll queries exabgp and delivers the metrics built from its answers
+func (e *StandaloneExporter) poll(ctx context.Context, ch chan<- prometheus.Metric) {
+	ribs, peers, neighbors, err := e.scrape(ctx, ch)
 	if err != nil {
 		level.Error(e.BaseExporter.logger).Log("err", err) // nolint:errcheck
 	} else {
+		e.collectInfo(ctx, ch)
+		seen := make(map[string]bool)
 		for _, u := range peers {
+			seen[u.IPAddress] = true
 			desc := newSummaryMetric("peer")
 			isUp := 0
 			if u.Status != "down" {
@@ -61,105 +294,356 @@ func (e *StandaloneExporter) Collect(ch chan<- prometheus.Metric) {
 			}
 			m := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(isUp), u.IPAddress, u.AS)
 			ch <- m
-		}
-		for _, r := range ribs {
-			switch r.Family() {
-			case "ipv4 unicast":
-				v4u, _ := r.IPv4Unicast()
-				desc := newRibMetric("route")
-
-				// Transform ASPath to string
-				asPathLines := []string{}
-				for _, communityAS := range v4u.Attributes.ASPath {
-					asPathLines = append(asPathLines, strconv.Itoa(communityAS))
-				}
-
-				m := prometheus.MustNewConstMetric(
-					desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
-					r.LocalIP, r.LocalAS, v4u.NLRI, r.Family(),
-					strconv.Itoa(int(v4u.Attributes.Med)),
-					strconv.Itoa(v4u.Attributes.LocalPreference),
-					strings.Join(asPathLines, " "),
-					strings.Join(v4u.Attributes.Community, " "),
+			if u.UptimeError != nil {
+				// nolint:errcheck
+				level.Error(e.BaseExporter.logger).Log(
+					"msg", "unable to parse uptime", "peer_ip", u.IPAddress, "err", u.UptimeError,
 				)
-				ch <- m
-			case "ipv6 unicast":
-				v6u, _ := r.IPv6Unicast()
-				desc := newRibMetric("route")
-
-				// Transform ASPath to string
-				asPathLines := []string{}
-				for _, communityAS := range v6u.Attributes.ASPath {
-					asPathLines = append(asPathLines, strconv.Itoa(communityAS))
-				}
-
+				e.BaseExporter.parseFailures.Inc()
+				continue
+			}
+			// exabgpcli only reports how long a session has been up for
+			if isUp == 1 {
+				desc := newStateChangeMetric("last_state_change_timestamp_seconds")
 				m := prometheus.MustNewConstMetric(
-					desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
-					r.LocalIP, r.LocalAS, v6u.NLRI, r.Family(),
-					strconv.Itoa(int(v6u.Attributes.Med)),
-					strconv.Itoa(v6u.Attributes.LocalPreference),
-					strings.Join(asPathLines, " "),
-					strings.Join(v6u.Attributes.Community, " "),
+					desc, prometheus.GaugeValue, float64(e.lastStateChange(u.IPAddress, u.State, u.Uptime).Unix()), u.IPAddress, u.AS,
+					u.State,
 				)
 				ch <- m
-			default:
-				// nolint:errcheck
-				level.Error(e.BaseExporter.logger).Log(
-					"msg", "unable to handle family",
-					"family", r.Family(),
-					"err", err,
+			}
+		}
+		// forget the peers removed from the configuration
+		for peer := range e.stateChanges {
+			if !seen[peer] {
+				delete(e.stateChanges, peer)
+			}
+		}
+		if e.NeighborExtensive {
+			e.collectNeighbors(ch, neighbors)
+		} else {
+			// the summary only counts the update messages
+			messagesDesc := newMessagesMetric("messages_total")
+			for _, u := range peers {
+				ch <- prometheus.MustNewConstMetric(
+					messagesDesc, prometheus.CounterValue, float64(u.Sent), u.IPAddress, u.AS, "send", "update",
+				)
+				ch <- prometheus.MustNewConstMetric(
+					messagesDesc, prometheus.CounterValue, float64(u.Received), u.IPAddress, u.AS, "receive", "update",
 				)
 			}
 		}
+		for _, direction := range []string{"send", "receive"} {
+			for _, r := range ribs[direction] {
+				switch r.Family() {
+				case "ipv4 unicast", "ipv6 unicast", "ipv4 multicast", "ipv6 multicast",
+					"ipv4 nlri-mpls", "ipv6 nlri-mpls", "ipv4 mpls-vpn", "ipv6 mpls-vpn":
+					route, err := r.Route()
+					if err != nil {
+						// nolint:errcheck
+						level.Error(e.BaseExporter.logger).Log(
+							"msg", "unable to parse route",
+							"details", r.Details,
+							"err", err,
+						)
+						e.BaseExporter.parseFailures.Inc()
+						continue
+					}
+					desc := newRibMetric("route")
+					m := prometheus.MustNewConstMetric(
+						desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
+						r.LocalIP, r.LocalAS, route.NLRI, r.Family(),
+						strconv.Itoa(int(route.Attributes.Med)),
+						strconv.Itoa(route.Attributes.LocalPreference),
+						asPathToString(route.Attributes.ASPath),
+						strings.Join(route.Attributes.Community, " "),
+						direction, route.RouteDistinguisher, labelsToString(route.Labels), route.PathID,
+						strings.Join(route.RouteTargets, " "),
+					)
+					ch <- m
+				case "ipv4 flow":
+					v4f, err := r.IPv4Flow()
+					if err != nil {
+						// nolint:errcheck
+						level.Error(e.BaseExporter.logger).Log(
+							"msg", "unable to parse flow",
+							"details", r.Details,
+							"err", err,
+						)
+						e.BaseExporter.parseFailures.Inc()
+						continue
+					}
+					desc := newFlowMetric("flow")
+					m := prometheus.MustNewConstMetric(
+						desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
+						r.LocalIP, r.LocalAS, r.Family(), v4f.Rule,
+						v4f.DestinationIPv4, v4f.SourceIPv4, v4f.Protocol,
+						v4f.DestinationPort, v4f.SourcePort,
+						flowAction(strings.Fields(v4f.ExtendedCommunity)),
+						direction,
+					)
+					ch <- m
+				case "ipv6 flow":
+					v6f, err := r.IPv6Flow()
+					if err != nil {
+						// nolint:errcheck
+						level.Error(e.BaseExporter.logger).Log(
+							"msg", "unable to parse flow",
+							"details", r.Details,
+							"err", err,
+						)
+						e.BaseExporter.parseFailures.Inc()
+						continue
+					}
+					desc := newFlowMetric("flow")
+					m := prometheus.MustNewConstMetric(
+						desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
+						r.LocalIP, r.LocalAS, r.Family(), v6f.Rule,
+						v6f.DestinationIPv6, v6f.SourceIPv6, v6f.Protocol,
+						v6f.DestinationPort, v6f.SourcePort,
+						flowAction(strings.Fields(v6f.ExtendedCommunity)),
+						direction,
+					)
+					ch <- m
+				case "l2vpn vpls":
+					vpls, err := r.L2VPNVpls()
+					if err != nil {
+						// nolint:errcheck
+						level.Error(e.BaseExporter.logger).Log(
+							"msg", "unable to parse vpls",
+							"details", r.Details,
+							"err", err,
+						)
+						e.BaseExporter.parseFailures.Inc()
+						continue
+					}
+					desc := newVplsMetric("vpls")
+					m := prometheus.MustNewConstMetric(
+						desc, prometheus.GaugeValue, float64(1), r.PeerIP, r.PeerAS,
+						r.LocalIP, r.LocalAS, vpls.RouteDistinguisher,
+						strconv.Itoa(vpls.Endpoint),
+						strconv.Itoa(vpls.Base),
+						strconv.Itoa(vpls.Offset),
+						strconv.Itoa(vpls.Size),
+						direction,
+					)
+					ch <- m
+				default:
+					// nolint:errcheck
+					level.Error(e.BaseExporter.logger).Log(
+						"msg", "unable to handle family",
+						"family", r.Family(),
+						"err", err,
+					)
+					e.BaseExporter.unknownFamily.WithLabelValues(r.Family()).Inc()
+				}
+			}
+		}
 	}
-
-	ch <- e.BaseExporter.totalScrapes
-	ch <- e.BaseExporter.parseFailures
 }
 
-func (e *StandaloneExporter) scrape(ch chan<- prometheus.Metric) ([]*text.RIBMessage, []*text.NeighborSummary, error) {
+func (e *StandaloneExporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) (map[string][]*text.RIBMessage, []*text.NeighborSummary, []*text.Neighbor, error) {
 	var ns []*text.NeighborSummary
-	var rs []*text.RIBMessage
+	var neighbors []*text.Neighbor
+	rs := make(map[string][]*text.RIBMessage)
 
-	res, err := e.getSummary()
+	var res []byte
+	var err error
+	if e.NeighborExtensive {
+		res, err = e.getNeighbors(ctx)
+	} else {
+		res, err = e.getSummary(ctx)
+	}
 	if err != nil {
+		e.version = ""
 		e.BaseExporter.setExabgpStatus(ch, 0)
 		e.BaseExporter.parseFailures.Inc()
-		return rs, ns, fmt.Errorf("stdout: %s, error: %s", string(res), err.Error())
+		return rs, ns, neighbors, fmt.Errorf("stdout: %s, error: %s", string(res), err.Error())
+	}
+	var status []*text.NeighborSummary
+	if e.NeighborExtensive {
+		neighbors, err = text.NeighborsFromBytes(res)
+		for _, n := range neighbors {
+			status = append(status, n.Summary())
+		}
+	} else {
+		status, err = text.SummariesFromBytes(res)
 	}
-	e.BaseExporter.setExabgpStatus(ch, 1)
-	status, err := text.SummariesFromBytes(res)
 	if err != nil {
+		e.BaseExporter.setExabgpStatus(ch, 1)
 		e.BaseExporter.parseFailures.Inc()
-		return rs, ns, err
+		return rs, ns, nil, err
 	}
-	ribres, riberr := e.getRIB()
-	if riberr != nil {
-		e.BaseExporter.setExabgpStatus(ch, 0)
-		e.BaseExporter.parseFailures.Inc()
-		return rs, ns, fmt.Errorf("stdout: %s, error: %s", string(ribres), riberr.Error())
+	directions := []string{"send"}
+	if e.AdjRibIn {
+		directions = append(directions, "receive")
+	}
+	for _, direction := range directions {
+		ribres, riberr := e.getRIB(ctx, direction)
+		if riberr != nil {
+			e.BaseExporter.setExabgpStatus(ch, 0)
+			e.BaseExporter.parseFailures.Inc()
+			return rs, ns, nil, fmt.Errorf("stdout: %s, error: %s", string(ribres), riberr.Error())
+		}
+		ribs, ribserr := text.RibFromBytes(ribres)
+		if ribserr != nil {
+			e.BaseExporter.setExabgpStatus(ch, 1)
+			return rs, ns, nil, ribserr
+		}
+		rs[direction] = ribs
+	}
+	e.BaseExporter.setExabgpStatus(ch, 1)
+	return rs, status, neighbors, nil
+}
+
+// collectNeighbors exports the counters and settings of the sessions shown
+// by show neighbor extensive, exabgp reports the capabilities and families
+// it advertised (send) and those of the peer (receive)
+func (e *StandaloneExporter) collectNeighbors(ch chan<- prometheus.Metric, neighbors []*text.Neighbor) {
+	messagesDesc := newMessagesMetric("messages_total")
+	capabilityDesc := newCapabilityMetric("capability_info")
+	familyDesc := newFamilyMetric("family_info")
+	holdTimeDesc := newHoldTimeMetric("hold_time_seconds")
+	sessionDesc := newSessionInfoMetric("session_info")
+	stateChangeDesc := newStateChangeMetric("last_state_change_timestamp_seconds")
+	for _, n := range neighbors {
+		// the established sessions are exported along with the summary
+		if n.State != "established" && n.DownTime > 0 && n.UptimeError == nil {
+			ch <- prometheus.MustNewConstMetric(
+				stateChangeDesc, prometheus.GaugeValue, float64(e.lastStateChange(n.PeerAddress, n.State, n.DownTime).Unix()),
+				n.PeerAddress, n.PeerAS, n.State,
+			)
+		}
+		for kind, m := range n.Messages {
+			if kind == "total" {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				messagesDesc, prometheus.CounterValue, float64(m.Sent), n.PeerAddress, n.PeerAS, "send", kind,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				messagesDesc, prometheus.CounterValue, float64(m.Received), n.PeerAddress, n.PeerAS, "receive", kind,
+			)
+		}
+		for name, c := range n.Capabilities {
+			for direction, v := range map[string]string{"send": c.Local, "receive": c.Remote} {
+				if v == "enabled" {
+					ch <- prometheus.MustNewConstMetric(
+						capabilityDesc, prometheus.GaugeValue, float64(1), n.PeerAddress, n.PeerAS, direction, name,
+					)
+				}
+			}
+		}
+		for name, f := range n.Families {
+			for direction, v := range map[string]string{"send": f.Local, "receive": f.Remote} {
+				if v == "enabled" {
+					ch <- prometheus.MustNewConstMetric(
+						familyDesc, prometheus.GaugeValue, float64(1), n.PeerAddress, n.PeerAS, direction, name,
+					)
+				}
+			}
+		}
+		// the hold time is only known once the peer sent its open message
+		local, lerr := strconv.Atoi(n.LocalHoldTime)
+		peer, perr := strconv.Atoi(n.PeerHoldTime)
+		if lerr == nil && perr == nil {
+			holdTime := local
+			if peer < local {
+				holdTime = peer
+			}
+			ch <- prometheus.MustNewConstMetric(
+				holdTimeDesc, prometheus.GaugeValue, float64(holdTime), n.PeerAddress, n.PeerAS,
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			sessionDesc, prometheus.GaugeValue, float64(1), n.PeerAddress, n.PeerAS,
+			n.LocalAddress, available(n.LocalAS), available(n.LocalRouterID), available(n.PeerRouterID),
+		)
 	}
-	ribs, ribserr := text.RibFromBytes(ribres)
-	if ribserr != nil {
-		return rs, ns, ribserr
+}
+
+// available returns the value or nothing when exabgp doesn't know it
+func available(v string) string {
+	if v == text.NotAvailable {
+		return ""
 	}
-	return ribs, status, nil
+	return v
 }
-func (e *StandaloneExporter) getSummary() ([]byte, error) {
-	return e.runExaBGPCLI(showSummarySubcommand)
+
+// collectInfo exports the exabgp version, exabgpcli talks to the exabgp
+// running on this host whose pid it doesn't tell
+func (e *StandaloneExporter) collectInfo(ctx context.Context, ch chan<- prometheus.Metric) {
+	if e.version == "" {
+		res, err := e.runExaBGPCLI(ctx, versionSubcommand)
+		if err != nil {
+			// nolint:errcheck
+			level.Error(e.BaseExporter.logger).Log(
+				"msg", "unable to get exabgp version", "stdout", string(res), "err", err,
+			)
+			return
+		}
+		version, err := text.VersionFromBytes(res)
+		if err != nil {
+			level.Error(e.BaseExporter.logger).Log("err", err) // nolint:errcheck
+			e.BaseExporter.parseFailures.Inc()
+			return
+		}
+		e.version = version
+	}
+	host, _ := os.Hostname()
+	ch <- prometheus.MustNewConstMetric(newInfoMetric("info"), prometheus.GaugeValue, float64(1), e.version, host, "")
+}
+
+func (e *StandaloneExporter) getSummary(ctx context.Context) ([]byte, error) {
+	return e.runExaBGPCLI(ctx, showSummarySubcommand)
+}
+
+func (e *StandaloneExporter) getNeighbors(ctx context.Context) ([]byte, error) {
+	return e.runExaBGPCLI(ctx, showNeighborSubcommand)
 }
 
-func (e *StandaloneExporter) getRIB() ([]byte, error) {
-	return e.runExaBGPCLI(showAdjRibSubcommand)
+// getRIB returns the routes sent to (adj-rib out) or received from (adj-rib
+// in) the peers
+func (e *StandaloneExporter) getRIB(ctx context.Context, direction string) ([]byte, error) {
+	if direction == "receive" {
+		return e.runExaBGPCLI(ctx, showAdjRibInSubcommand)
+	}
+	return e.runExaBGPCLI(ctx, showAdjRibOutSubcommand)
 }
 
-func (e *StandaloneExporter) runExaBGPCLI(subcommand []string) ([]byte, error) {
-	args := []string{"--root", e.ExaBGPRoot}
-	args = append(args, subcommand...)
-	cmd := exec.Command(e.ExaBGPCLI, args...)
-	var se, so bytes.Buffer
-	cmd.Stderr = &se
-	cmd.Stdout = &so
-	err := cmd.Run()
-	return so.Bytes(), err
+func (e *StandaloneExporter) runExaBGPCLI(ctx context.Context, subcommand []string) ([]byte, error) {
+	command := strings.Join(subcommand, " ")
+	start := time.Now()
+	defer func() {
+		e.cliDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	}()
+
+	var out []byte
+	var err error
+	if e.Client != nil {
+		out, err = e.Client.Send(ctx, command)
+	} else {
+		args := []string{"--root", e.ExaBGPRoot}
+		args = append(args, subcommand...)
+		cmd := exec.CommandContext(ctx, e.ExaBGPCLI, args...)
+		// exabgpcli may leave children behind which would keep the pipes open
+		killProcessGroup(cmd)
+		// don't wait for whatever still holds the output once killed
+		cmd.WaitDelay = time.Second
+		var se, so bytes.Buffer
+		cmd.Stderr = &se
+		cmd.Stdout = &so
+		err = cmd.Run()
+		out = so.Bytes()
+	}
+	// the command may have finished right before the deadline, the client
+	// may also give up on its own deadline
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		e.cliTimeouts.Inc()
+		return out, fmt.Errorf("%s timed out: %w", command, ctx.Err())
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		e.cliTimeouts.Inc()
+		return out, fmt.Errorf("%s timed out: %w", command, err)
+	}
+	return out, err
 }
@@ -0,0 +1,215 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gizmoguy/exabgp_exporter/pkg/exabgp/client"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient answers the commands it knows about
+type testClient map[string]string
+
+func (c testClient) Send(ctx context.Context, command string) ([]byte, error) {
+	if answer, ok := c[command]; ok {
+		return []byte(answer), nil
+	}
+	return nil, client.ErrCommand
+}
+
+var testAnswers = testClient{
+	"show neighbor summary": `Peer            AS        up/down state       |     #sent     #recvd
+127.0.0.1       64496        down idle                  0          0
+192.168.1.1     64496     0:00:01 established          45          0
+`,
+	"show adj-rib out extensive": `neighbor 127.0.0.1 local-ip 127.0.0.1 local-as 64496 peer-as 64496 router-id 1.1.1.1 family-allowed in-open ipv4 unicast 192.168.88.248/29 next-hop self med 100
+`,
+	"version": "exabgp 4.2.21\n",
+}
+
+// testCollector collects whatever the function delivers
+type testCollector func(ch chan<- prometheus.Metric)
+
+func (c testCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c testCollector) Collect(ch chan<- prometheus.Metric) {
+	c(ch)
+}
+
+func testStale(stale int) string {
+	return `# HELP exabgp_exporter_stale ` + staleHelp + `
+# TYPE exabgp_exporter_stale gauge
+exabgp_exporter_stale ` + strconv.Itoa(stale) + `
+`
+}
+
+func testStandaloneExporter(t *testing.T) *StandaloneExporter {
+	e, err := NewStandaloneExporter("exabgpcli", "/etc/exabgp", log.NewNopLogger())
+	require.NoError(t, err)
+	return e
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	tc := map[string]struct {
+		timeout  time.Duration
+		header   string
+		expected time.Duration
+	}{
+		"no header":         {0, "", defaultScrapeTimeout - scrapeTimeoutOffset},
+		"header":            {0, "5", 5*time.Second - scrapeTimeoutOffset},
+		"fractional header": {0, "2.5", 2 * time.Second},
+		"invalid header":    {0, "soon", defaultScrapeTimeout - scrapeTimeoutOffset},
+		"too short":         {0, "0.8", 800 * time.Millisecond},
+		"configured":        {3 * time.Second, "5", 3 * time.Second},
+	}
+	for name, test := range tc {
+		t.Run(name, func(t *testing.T) {
+			e := testStandaloneExporter(t)
+			e.Timeout = test.timeout
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if test.header != "" {
+				r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", test.header)
+			}
+			require.Equal(t, test.expected, e.scrapeTimeout(r))
+		})
+	}
+}
+
+func TestSnapshotBeforeRefresh(t *testing.T) {
+	e := testStandaloneExporter(t)
+	e.Client = testAnswers
+	e.Interval = time.Minute
+	snapshot := testCollector(e.collectSnapshot)
+
+	require.NoError(t, testutil.CollectAndCompare(snapshot, strings.NewReader(testStale(1)), "exabgp_exporter_stale"))
+	require.Equal(t, 0, testutil.CollectAndCount(snapshot, "exabgp_exporter_last_refresh_timestamp_seconds"))
+	require.Equal(t, 0, testutil.CollectAndCount(snapshot, "exabgp_state_peer"))
+}
+
+func TestSnapshotRefresh(t *testing.T) {
+	e := testStandaloneExporter(t)
+	e.Client = testAnswers
+	e.Interval = time.Minute
+	snapshot := testCollector(e.collectSnapshot)
+
+	e.refresh(context.Background())
+	require.NoError(t, testutil.CollectAndCompare(snapshot, strings.NewReader(testStale(0)), "exabgp_exporter_stale"))
+	require.Equal(t, 1, testutil.CollectAndCount(snapshot, "exabgp_exporter_last_refresh_timestamp_seconds"))
+	require.Equal(t, 2, testutil.CollectAndCount(snapshot, "exabgp_state_peer"))
+	require.Equal(t, 1, testutil.CollectAndCount(snapshot, "exabgp_state_route"))
+	require.Equal(t, 1, testutil.CollectAndCount(snapshot, "exabgp_up"))
+
+	// no poll finished within twice the interval
+	e.lastRefresh = time.Now().Add(-2*e.Interval - time.Second)
+	require.NoError(t, testutil.CollectAndCompare(snapshot, strings.NewReader(testStale(1)), "exabgp_exporter_stale"))
+	// unless the timeout allows for a longer poll
+	e.Timeout = 2 * e.Interval
+	require.NoError(t, testutil.CollectAndCompare(snapshot, strings.NewReader(testStale(0)), "exabgp_exporter_stale"))
+}
+
+func TestRunStops(t *testing.T) {
+	e := testStandaloneExporter(t)
+	e.Client = testAnswers
+	e.Interval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	e.Run(ctx)
+	cancel()
+	select {
+	case <-e.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't stop once its context was done")
+	}
+	e.snapshotMutex.RLock()
+	defer e.snapshotMutex.RUnlock()
+	require.False(t, e.lastRefresh.IsZero())
+}
+
+// testCountingClient counts the commands sent to exabgp
+type testCountingClient struct {
+	client.Client
+	sent map[string]int
+}
+
+func (c *testCountingClient) Send(ctx context.Context, command string) ([]byte, error) {
+	c.sent[command]++
+	return c.Client.Send(ctx, command)
+}
+
+func TestVersionCached(t *testing.T) {
+	e := testStandaloneExporter(t)
+	answers := testClient{}
+	for command, answer := range testAnswers {
+		answers[command] = answer
+	}
+	c := &testCountingClient{Client: answers, sent: make(map[string]int)}
+	e.Client = c
+	scrape := testCollector(func(ch chan<- prometheus.Metric) {
+		e.poll(context.Background(), ch)
+	})
+
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_info"))
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_info"))
+	require.Equal(t, 1, c.sent["version"])
+
+	// exabgp may have been upgraded while it didn't answer
+	delete(answers, "show neighbor summary")
+	require.Equal(t, 0, testutil.CollectAndCount(scrape, "exabgp_info"))
+	answers["show neighbor summary"] = testAnswers["show neighbor summary"]
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_info"))
+	require.Equal(t, 2, c.sent["version"])
+}
+
+// testSlowClient gives up on its own deadline like the native clients
+type testSlowClient struct{}
+
+func (testSlowClient) Send(ctx context.Context, command string) ([]byte, error) {
+	return nil, fmt.Errorf("no answer from exabgp: %w", os.ErrDeadlineExceeded)
+}
+
+func TestRunExaBGPCLIClientTimeout(t *testing.T) {
+	e := testStandaloneExporter(t)
+	e.Client = testSlowClient{}
+
+	_, err := e.runExaBGPCLI(context.Background(), showSummarySubcommand)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.Equal(t, float64(1), testutil.ToFloat64(e.cliTimeouts))
+}
+
+func TestPollInvalidUptime(t *testing.T) {
+	e := testStandaloneExporter(t)
+	answers := testClient{}
+	for command, answer := range testAnswers {
+		answers[command] = answer
+	}
+	answers["show neighbor summary"] = `Peer            AS        up/down state       |     #sent     #recvd
+192.168.1.1     64496     0:00:01 established          45          0
+192.168.1.2     64496        soon established          45          0
+`
+	e.Client = answers
+	scrape := testCollector(func(ch chan<- prometheus.Metric) {
+		e.poll(context.Background(), ch)
+	})
+
+	// only the state change of the peer is skipped
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_up"))
+	require.Equal(t, 2, testutil.CollectAndCount(scrape, "exabgp_state_peer"))
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_peer_last_state_change_timestamp_seconds"))
+	require.Equal(t, 1, testutil.CollectAndCount(scrape, "exabgp_state_route"))
+
+	failures := testutil.ToFloat64(e.parseFailures)
+	ch := make(chan prometheus.Metric, 100)
+	e.poll(context.Background(), ch)
+	require.Equal(t, failures+1, testutil.ToFloat64(e.parseFailures))
+}
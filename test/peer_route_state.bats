@@ -29,25 +29,25 @@ load 'common'
 @test "verify peer routes with as-path, community, local preference and med ipv4 announce - embedded" {
   run announce_routes
   run get_peer_metrics
-  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",family="ipv4 unicast".+,local_preference="100",med="200",nlri="10\.0\.0\.0/24".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",direction="send",family="ipv4 unicast".+,local_preference="100",med="200",nlri="10\.0\.0\.0/24".*\} 1$'
 }
 
 @test "verify peer routes with as-path, community, local preference and med ipv6 announce - embedded" {
   run announce_routes
   run get_peer_metrics
-  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",family="ipv6 unicast".+,local_preference="100",med="200",nlri="2001:db8:2000::/64".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",direction="send",family="ipv6 unicast".+,local_preference="100",med="200",nlri="2001:db8:2000::/64".*\} 1$'
 }
 
 @test "verify peer routes with multiple as-paths and communities ipv4 announce - embedded" {
   run announce_routes
   run get_peer_metrics
-  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",family="ipv4 unicast".+,nlri="10\.0\.1\.0/24".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",direction="send",family="ipv4 unicast".+,nlri="10\.0\.1\.0/24".*\} 1$'
 }
 
 @test "verify peer routes with multiple as-paths and communities ipv6 announce - embedded" {
   run announce_routes
   run get_peer_metrics
-  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",family="ipv6 unicast".+,nlri="2001:db8:3000::/64".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",direction="send",family="ipv6 unicast".+,nlri="2001:db8:3000::/64".*\} 1$'
 }
 
 @test "verify peer routes ipv4 announce - standalone" {
@@ -65,25 +65,25 @@ load 'common'
 @test "verify peer routes with as-path, community, local preference and med ipv4 announce - standalone" {
   run announce_routes
   run get_peer_metrics 9570
-  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",family="ipv4 unicast".+,local_preference="100",med="200",nlri="10\.0\.0\.0/24".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",direction="send",family="ipv4 unicast".+,local_preference="100",med="200",nlri="10\.0\.0\.0/24".*\} 1$'
 }
 
 @test "verify peer routes with as-path, community, local preference and med ipv6 announce - standalone" {
   run announce_routes
   run get_peer_metrics 9570
-  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",family="ipv6 unicast".+,local_preference="100",med="200",nlri="2001:db8:2000::/64".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001",communities="65001:1234",direction="send",family="ipv6 unicast".+,local_preference="100",med="200",nlri="2001:db8:2000::/64".*\} 1$'
 }
 
 @test "verify peer routes with multiple as-paths and communities ipv4 announce - standalone" {
   run announce_routes
   run get_peer_metrics 9570
-  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",family="ipv4 unicast".+,nlri="10\.0\.1\.0/24".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",direction="send",family="ipv4 unicast".+,nlri="10\.0\.1\.0/24".*\} 1$'
 }
 
 @test "verify peer routes with multiple as-paths and communities ipv6 announce - standalone" {
   run announce_routes
   run get_peer_metrics 9570
-  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",family="ipv6 unicast".+,nlri="2001:db8:3000::/64".*\} 1$'
+  assert_line --regexp '^exabgp_state_route\{as_path="65001 65002",communities="65001:1234 65001:5678",direction="send",family="ipv6 unicast".+,nlri="2001:db8:3000::/64".*\} 1$'
 }
 
 @test "verify count of peer routes - embedded" {
@@ -18,6 +18,11 @@ load 'common'
   assert_line --regexp '^exabgp_state_peer\{.*\} 0$'
 }
 
+@test "verify peer routes are withdrawn on peer down - embedded" {
+  run get_peer_metrics
+  refute_line --regexp '^exabgp_state_route\{.*\} 1$'
+}
+
 @test "verify peer_state is down - standalone" {
   if [[ $(get_exabgp_version) == "4.2."* ]]; then
     skip "exabgp 4.2.x doesn't report down peers in exabgpcli (issue #996)"